@@ -0,0 +1,86 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A band of days remaining and its interval of checks.
+// Hosts with more days remaining than days are checked every interval.
+type checkBand struct {
+	days     int
+	interval time.Duration
+}
+
+// Read ADAPTIVE_BANDS like 60=168h,7=24h,0=1h if ADAPTIVE_CHECKS is true,
+// sorted by days descending. Nil if adaptive checks are disabled.
+// Exit process if they're malformed.
+func readCheckBands() []checkBand {
+	if !envOptionalBool("ADAPTIVE_CHECKS", false) {
+		return nil
+	}
+	var bands []checkBand
+	for _, spec := range splitOptional(envOptional("ADAPTIVE_BANDS", "60=168h,7=24h,0=1h")) {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 {
+			log.Fatalf("Invalid ADAPTIVE_BANDS: %q", spec)
+		}
+		days, err := strconv.Atoi(kv[0])
+		if err != nil {
+			log.Fatalf("Invalid days of ADAPTIVE_BANDS: %q", spec)
+		}
+		interval, err := time.ParseDuration(kv[1])
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid interval of ADAPTIVE_BANDS: %q", spec)
+		}
+		bands = append(bands, checkBand{days, interval})
+	}
+	if len(bands) == 0 {
+		log.Fatalf("ADAPTIVE_BANDS is empty")
+	}
+	sort.Slice(bands, func(i, j int) bool { return bands[i].days > bands[j].days })
+	return bands
+}
+
+// The shortest interval of bands, or 0 without bands.
+func shortestBand(bands []checkBand) time.Duration {
+	var shortest time.Duration
+	for _, b := range bands {
+		if shortest == 0 || b.interval < shortest {
+			shortest = b.interval
+		}
+	}
+	return shortest
+}
+
+// The interval of checks of a certificate checked at checkedAt.
+// 0 unless adaptive checks are enabled.
+func (config *config) adaptiveInterval(r *result, checkedAt time.Time) time.Duration {
+	if len(config.checkBands) == 0 {
+		return 0
+	}
+	days := r.notAfter.Sub(checkedAt).Hours() / 24
+	for _, b := range config.checkBands {
+		if days > float64(b.days) {
+			return b.interval
+		}
+	}
+	return config.checkBands[len(config.checkBands)-1].interval
+}
+
+// When a host is checked next by its adaptive interval, or nil unless
+// it succeeded with adaptive checks enabled.
+func (config *config) nextCheck(c *hostCheck) *time.Time {
+	if c.result == nil {
+		return nil
+	}
+	interval := config.adaptiveInterval(c.result, c.checkedAt)
+	if interval == 0 {
+		return nil
+	}
+	next := c.checkedAt.Add(interval)
+	return &next
+}
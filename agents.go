@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A header of the HMAC-SHA256 signature of results pushed by agents.
+const agentSignatureHeader = "X-Sslreminder-Signature"
+
+// Results of checks pushed by an agent.
+type agentReport struct {
+	Agent           string       `json:"agent"`
+	IntervalSeconds int64        `json:"interval_seconds"`
+	SentAt          time.Time    `json:"sent_at"`
+	Checks          []agentCheck `json:"checks"`
+}
+
+// A check by an agent.
+type agentCheck struct {
+	Host            string            `json:"host"`
+	Source          string            `json:"source,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	CheckedAt       time.Time         `json:"checked_at"`
+	DurationSeconds float64           `json:"duration_seconds"`
+	// DER certificates, leaf first.
+	Chain        [][]byte `json:"chain,omitempty"`
+	FileMismatch bool     `json:"file_mismatch,omitempty"`
+	TLSVersion   uint16   `json:"tls_version,omitempty"`
+	CipherSuite  uint16   `json:"cipher_suite,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// Convert a check to push.
+func newAgentCheck(c *hostCheck) agentCheck {
+	ac := agentCheck{
+		Host:            c.target.host,
+		Source:          c.target.source,
+		Tags:            c.target.tags,
+		CheckedAt:       c.checkedAt,
+		DurationSeconds: c.duration.Seconds(),
+	}
+	if c.err != nil {
+		ac.Error = c.err.Error()
+		return ac
+	}
+	for _, cert := range c.result.chain {
+		ac.Chain = append(ac.Chain, cert.Raw)
+	}
+	ac.FileMismatch = c.result.fileMismatch
+	ac.TLSVersion = c.result.tlsVersion
+	ac.CipherSuite = c.result.cipherSuite
+	return ac
+}
+
+// Convert a pushed check back, attributing it to the agent.
+func (ac agentCheck) hostCheck(agent string) (*hostCheck, error) {
+	source := "agent " + agent
+	if ac.Source != "" {
+		source += " " + ac.Source
+	}
+	c := &hostCheck{
+		target:    target{host: ac.Host, source: source, tags: ac.Tags},
+		checkedAt: ac.CheckedAt,
+		duration:  time.Duration(ac.DurationSeconds * float64(time.Second)),
+	}
+	if ac.Error != "" {
+		c.err = errors.New(ac.Error)
+		return c, nil
+	}
+	var chain []*x509.Certificate
+	for _, der := range ac.Chain {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid certificate of %v: %v", ac.Host, err)
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("No certificates of %v", ac.Host)
+	}
+	c.result = &result{
+		notAfter:     chain[0].NotAfter,
+		notBefore:    chain[0].NotBefore,
+		chain:        chain,
+		fileMismatch: ac.FileMismatch,
+		tlsVersion:   ac.TLSVersion,
+		cipherSuite:  ac.CipherSuite,
+	}
+	return c, nil
+}
+
+// Sign a body with AGENT_SECRET.
+func signAgentReport(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// The latest results pushed by agents to the central instance.
+type agentRegistry struct {
+	mu     sync.Mutex
+	secret []byte
+	// Maximum difference between sent_at of reports and now.
+	skew time.Duration
+	// The latest reports and when they were received by agents.
+	reports  map[string]*agentReport
+	checks   map[string][]*hostCheck
+	received map[string]time.Time
+}
+
+// Read AGENT_SECRET to accept results from agents.
+// Returns nil if it's not set.
+func readAgentRegistry() *agentRegistry {
+	secret := envOptional("AGENT_SECRET", "")
+	if len(secret) == 0 {
+		return nil
+	}
+	return &agentRegistry{
+		secret:   []byte(secret),
+		skew:     envOptionalDuration("AGENT_CLOCK_SKEW", 5*time.Minute),
+		reports:  make(map[string]*agentReport),
+		checks:   make(map[string][]*hostCheck),
+		received: make(map[string]time.Time),
+	}
+}
+
+// Accept a report of an agent.
+// Rejects replays, sent before the last accepted report of the agent or
+// more than AGENT_CLOCK_SKEW away from now.
+func (a *agentRegistry) accept(report *agentReport, now time.Time) error {
+	if report.Agent == "" || report.IntervalSeconds <= 0 {
+		return fmt.Errorf("Agent and interval are required")
+	}
+	if d := now.Sub(report.SentAt); d > a.skew || d < -a.skew {
+		return fmt.Errorf("Report of %v sent at %v is outside AGENT_CLOCK_SKEW",
+			report.Agent, report.SentAt)
+	}
+	checks := make([]*hostCheck, 0, len(report.Checks))
+	for _, ac := range report.Checks {
+		if err := validateHost(ac.Host); err != nil {
+			return err
+		}
+		c, err := ac.hostCheck(report.Agent)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, c)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if prev, ok := a.reports[report.Agent]; ok && !report.SentAt.After(prev.SentAt) {
+		return fmt.Errorf("Report of %v sent at %v is not newer than %v",
+			report.Agent, report.SentAt, prev.SentAt)
+	}
+	a.reports[report.Agent] = report
+	a.checks[report.Agent] = checks
+	a.received[report.Agent] = now
+	return nil
+}
+
+// Whether an agent hasn't reported within twice its interval.
+// The caller must hold the lock.
+func (a *agentRegistry) overdue(agent string, now time.Time) bool {
+	interval := time.Duration(a.reports[agent].IntervalSeconds) * time.Second
+	return now.Sub(a.received[agent]) > 2*interval
+}
+
+// The latest checks pushed by agents, sorted by agents.
+// Checks of overdue agents are dropped, since they're no longer current;
+// the agents are reminded as stale instead.
+func (a *agentRegistry) latestChecks(now time.Time) []*hostCheck {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	agents := make([]string, 0, len(a.checks))
+	for agent := range a.checks {
+		if !a.overdue(agent, now) {
+			agents = append(agents, agent)
+		}
+	}
+	sort.Strings(agents)
+	var checks []*hostCheck
+	for _, agent := range agents {
+		checks = append(checks, a.checks[agent]...)
+	}
+	return checks
+}
+
+// Agents which haven't reported within twice their intervals, like
+// "dc1 (last reported at ...)".
+func (a *agentRegistry) stale(now time.Time) []string {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var agents []string
+	for agent := range a.reports {
+		if a.overdue(agent, now) {
+			agents = append(agents, fmt.Sprintf("%v (last reported at %v)", agent, a.received[agent]))
+		}
+	}
+	sort.Strings(agents)
+	return agents
+}
+
+// The section of stale agents in mails.
+func staleAgentsText(rem *reminder) string {
+	if len(rem.StaleAgents) == 0 {
+		return ""
+	}
+	return "Following agents haven't reported within their intervals:\n" +
+		strings.Join(rem.StaleAgents, "\n") + "\n\n"
+}
+
+// Accept results pushed by agents like POST /agent/results,
+// signed with AGENT_SECRET.
+func agentResultsHandler(config *config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config.agents == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 10<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		expected := signAgentReport(config.agents.secret, body)
+		if !hmac.Equal([]byte(r.Header.Get(agentSignatureHeader)), []byte(expected)) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		var report agentReport
+		if err := json.Unmarshal(body, &report); err != nil {
+			http.Error(w, fmt.Sprintf("malformed JSON: %v", err),
+				http.StatusBadRequest)
+			return
+		}
+		if err := config.agents.accept(&report, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Accepted %v checks from agent %v", len(report.Checks), report.Agent)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Push checks to AGGREGATOR_URL.
+func pushAgentReport(url string, secret []byte, report *agentReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(agentSignatureHeader, signAgentReport(secret, body))
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return nil
+}
+
+// Check HOSTS every CHECK_INTERVAL like "sslreminder agent", and push
+// results to the central instance at AGGREGATOR_URL, which reminds.
+func agentCommand(args []string) {
+	if len(args) != 0 {
+		log.Fatalf("Usage: sslreminder agent")
+	}
+	name := envMandatory("AGENT_NAME")
+	url := strings.TrimSuffix(envMandatory("AGGREGATOR_URL"), "/") + "/agent/results"
+	secret := []byte(envMandatory("AGENT_SECRET"))
+	hosts := readHosts()
+	interval := envOptionalDuration("CHECK_INTERVAL", 24*time.Hour)
+	timeout := envOptionalDuration("CHECK_TIMEOUT", 30*time.Second)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report := &agentReport{
+			Agent:           name,
+			IntervalSeconds: int64(interval.Seconds()),
+		}
+		for _, c := range checkTargets(hosts, timeout) {
+			report.Checks = append(report.Checks, newAgentCheck(c))
+		}
+		report.SentAt = time.Now()
+		if err := pushAgentReport(url, secret, report); err != nil {
+			log.Printf("ERROR pushing results to %v: %v", url, err)
+		} else {
+			log.Printf("Pushed %v checks to %v", len(report.Checks), url)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestAgentRegistry() *agentRegistry {
+	return &agentRegistry{
+		secret:   []byte("secret"),
+		skew:     5 * time.Minute,
+		reports:  make(map[string]*agentReport),
+		checks:   make(map[string][]*hostCheck),
+		received: make(map[string]time.Time),
+	}
+}
+
+func testAgentReport(sentAt time.Time) *agentReport {
+	return &agentReport{
+		Agent:           "dc1",
+		IntervalSeconds: 3600,
+		SentAt:          sentAt,
+		Checks: []agentCheck{
+			{Host: "www.example.com", CheckedAt: sentAt, Error: "timeout"},
+		},
+	}
+}
+
+func TestAgentAcceptRejectsReplays(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := newTestAgentRegistry()
+	if err := a.accept(testAgentReport(now), now); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	later := now.Add(time.Minute)
+	if err := a.accept(testAgentReport(now), later); err == nil {
+		t.Errorf("accepted a report sent at the same time again")
+	}
+	if err := a.accept(testAgentReport(now.Add(-time.Second)), later); err == nil {
+		t.Errorf("accepted a report sent before the last one")
+	}
+	if err := a.accept(testAgentReport(later), later); err != nil {
+		t.Errorf("accept a newer report: %v", err)
+	}
+}
+
+func TestAgentAcceptRejectsSkew(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		sentAt time.Time
+		ok     bool
+	}{
+		{now, true},
+		{now.Add(-4 * time.Minute), true},
+		{now.Add(4 * time.Minute), true},
+		{now.Add(-6 * time.Minute), false},
+		{now.Add(6 * time.Minute), false},
+		{time.Time{}, false},
+	}
+	for _, c := range cases {
+		err := newTestAgentRegistry().accept(testAgentReport(c.sentAt), now)
+		if (err == nil) != c.ok {
+			t.Errorf("accept report sent at %v: got %v, want ok %v", c.sentAt, err, c.ok)
+		}
+	}
+}
+
+func TestAgentLatestChecksDropsOverdueAgents(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := newTestAgentRegistry()
+	if err := a.accept(testAgentReport(now), now); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if checks := a.latestChecks(now.Add(2 * time.Hour)); len(checks) != 1 {
+		t.Errorf("got %v checks within twice the interval, want 1", len(checks))
+	}
+	later := now.Add(2*time.Hour + time.Second)
+	if checks := a.latestChecks(later); len(checks) != 0 {
+		t.Errorf("got %v checks of an overdue agent, want 0", len(checks))
+	}
+	if stale := a.stale(later); len(stale) != 1 {
+		t.Errorf("got %v stale agents, want 1", len(stale))
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+)
+
+// The host a target was resolved from, which is its own host unless
+// it's checked per address by CHECK_ALL_IPS.
+func (t target) origin() string {
+	if t.resolvedFrom != "" {
+		return t.resolvedFrom
+	}
+	return t.host
+}
+
+// The address to connect to, like www.example.com:443 or 192.0.2.1:443.
+func (t target) dialAddr() string {
+	addr := hostPort(t.origin())
+	if t.ip == "" {
+		return addr
+	}
+	_, port, _ := net.SplitHostPort(addr)
+	return net.JoinHostPort(t.ip, port)
+}
+
+// Targets with hosts connected to replaced by a target per resolved
+// address, named like www.example.com@192.0.2.1.
+// Hosts failing to resolve are left as is so that their checks fail.
+func expandIPs(targets []target) []target {
+	var expanded []target
+	for _, t := range targets {
+		name, _, _ := net.SplitHostPort(hostPort(t.host))
+		if t.certFile != "" && !t.probe || len(t.certs) > 0 || net.ParseIP(name) != nil {
+			expanded = append(expanded, t)
+			continue
+		}
+		ips, err := net.LookupIP(name)
+		if err != nil || len(ips) == 0 {
+			log.Printf("ERROR resolving %v: %v", name, err)
+			expanded = append(expanded, t)
+			continue
+		}
+		for _, ip := range ips {
+			perIP := t
+			perIP.host = fmt.Sprintf("%v@%v", t.host, ip)
+			perIP.resolvedFrom = t.host
+			perIP.ip = ip.String()
+			expanded = append(expanded, perIP)
+		}
+	}
+	return expanded
+}
+
+// Mark results of new checks whose addresses serve certificates other
+// than the rest of the addresses of their hosts, among all checks.
+func markDivergentIPs(checks, carried []*hostCheck) {
+	serials := make(map[string]map[string]bool)
+	for _, c := range append(append([]*hostCheck(nil), checks...), carried...) {
+		if c.target.ip == "" || c.result == nil || len(c.result.chain) == 0 {
+			continue
+		}
+		origin := c.target.resolvedFrom
+		if serials[origin] == nil {
+			serials[origin] = make(map[string]bool)
+		}
+		serials[origin][c.result.chain[0].SerialNumber.Text(16)] = true
+	}
+	for _, c := range checks {
+		if c.target.ip == "" || c.result == nil || len(serials[c.target.resolvedFrom]) < 2 {
+			continue
+		}
+		var others []string
+		mine := c.result.chain[0].SerialNumber.Text(16)
+		for serial := range serials[c.target.resolvedFrom] {
+			if serial != mine {
+				others = append(others, serial)
+			}
+		}
+		sort.Strings(others)
+		c.result.ipDivergence = fmt.Sprintf("serial %v while other addresses of %v serve %v",
+			mine, c.target.resolvedFrom, others)
+	}
+}
@@ -0,0 +1,6 @@
+// Package api has the gRPC API of sslreminder for external clients.
+// Regenerate its code with protoc-gen-go and protoc-gen-go-grpc by
+// go generate ./api after changing sslreminder.proto.
+package api
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative sslreminder.proto
@@ -0,0 +1,590 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: sslreminder.proto
+
+package api
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// A result of a host, like a host in GET /status.
+type HostResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Host            string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Source          string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Tags            map[string]string      `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CheckedAt       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=checked_at,json=checkedAt,proto3" json:"checked_at,omitempty"`
+	DurationSeconds float64                `protobuf:"fixed64,5,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`
+	NotAfter        *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	DaysRemaining   float64                `protobuf:"fixed64,7,opt,name=days_remaining,json=daysRemaining,proto3" json:"days_remaining,omitempty"`
+	Tier            string                 `protobuf:"bytes,8,opt,name=tier,proto3" json:"tier,omitempty"`
+	Issuer          string                 `protobuf:"bytes,9,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	ChainDepth      int32                  `protobuf:"varint,10,opt,name=chain_depth,json=chainDepth,proto3" json:"chain_depth,omitempty"`
+	TlsVersion      string                 `protobuf:"bytes,11,opt,name=tls_version,json=tlsVersion,proto3" json:"tls_version,omitempty"`
+	CipherSuite     string                 `protobuf:"bytes,12,opt,name=cipher_suite,json=cipherSuite,proto3" json:"cipher_suite,omitempty"`
+	Findings        map[string]string      `protobuf:"bytes,13,rep,name=findings,proto3" json:"findings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Error           string                 `protobuf:"bytes,14,opt,name=error,proto3" json:"error,omitempty"`
+	ErrorClass      string                 `protobuf:"bytes,15,opt,name=error_class,json=errorClass,proto3" json:"error_class,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HostResult) Reset() {
+	*x = HostResult{}
+	mi := &file_sslreminder_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostResult) ProtoMessage() {}
+
+func (x *HostResult) ProtoReflect() protoreflect.Message {
+	mi := &file_sslreminder_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostResult.ProtoReflect.Descriptor instead.
+func (*HostResult) Descriptor() ([]byte, []int) {
+	return file_sslreminder_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HostResult) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *HostResult) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *HostResult) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *HostResult) GetCheckedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CheckedAt
+	}
+	return nil
+}
+
+func (x *HostResult) GetDurationSeconds() float64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+func (x *HostResult) GetNotAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotAfter
+	}
+	return nil
+}
+
+func (x *HostResult) GetDaysRemaining() float64 {
+	if x != nil {
+		return x.DaysRemaining
+	}
+	return 0
+}
+
+func (x *HostResult) GetTier() string {
+	if x != nil {
+		return x.Tier
+	}
+	return ""
+}
+
+func (x *HostResult) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *HostResult) GetChainDepth() int32 {
+	if x != nil {
+		return x.ChainDepth
+	}
+	return 0
+}
+
+func (x *HostResult) GetTlsVersion() string {
+	if x != nil {
+		return x.TlsVersion
+	}
+	return ""
+}
+
+func (x *HostResult) GetCipherSuite() string {
+	if x != nil {
+		return x.CipherSuite
+	}
+	return ""
+}
+
+func (x *HostResult) GetFindings() map[string]string {
+	if x != nil {
+		return x.Findings
+	}
+	return nil
+}
+
+func (x *HostResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *HostResult) GetErrorClass() string {
+	if x != nil {
+		return x.ErrorClass
+	}
+	return ""
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_sslreminder_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sslreminder_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_sslreminder_proto_rawDescGZIP(), []int{1}
+}
+
+type GetStatusResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Version           string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	LastCycleStarted  *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=last_cycle_started,json=lastCycleStarted,proto3" json:"last_cycle_started,omitempty"`
+	LastCycleFinished *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=last_cycle_finished,json=lastCycleFinished,proto3" json:"last_cycle_finished,omitempty"`
+	NextCycle         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=next_cycle,json=nextCycle,proto3" json:"next_cycle,omitempty"`
+	Stale             bool                   `protobuf:"varint,5,opt,name=stale,proto3" json:"stale,omitempty"`
+	Hosts             []*HostResult          `protobuf:"bytes,6,rep,name=hosts,proto3" json:"hosts,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GetStatusResponse) Reset() {
+	*x = GetStatusResponse{}
+	mi := &file_sslreminder_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusResponse) ProtoMessage() {}
+
+func (x *GetStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sslreminder_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetStatusResponse) Descriptor() ([]byte, []int) {
+	return file_sslreminder_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetStatusResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *GetStatusResponse) GetLastCycleStarted() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastCycleStarted
+	}
+	return nil
+}
+
+func (x *GetStatusResponse) GetLastCycleFinished() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastCycleFinished
+	}
+	return nil
+}
+
+func (x *GetStatusResponse) GetNextCycle() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextCycle
+	}
+	return nil
+}
+
+func (x *GetStatusResponse) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+func (x *GetStatusResponse) GetHosts() []*HostResult {
+	if x != nil {
+		return x.Hosts
+	}
+	return nil
+}
+
+type CheckHostRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A host with an optional port like www.example.com:8443.
+	Target string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	// 10 seconds if it's not given, and at most 60 seconds.
+	Timeout       *durationpb.Duration `protobuf:"bytes,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckHostRequest) Reset() {
+	*x = CheckHostRequest{}
+	mi := &file_sslreminder_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckHostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckHostRequest) ProtoMessage() {}
+
+func (x *CheckHostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sslreminder_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckHostRequest.ProtoReflect.Descriptor instead.
+func (*CheckHostRequest) Descriptor() ([]byte, []int) {
+	return file_sslreminder_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CheckHostRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *CheckHostRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+type ListHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=since,proto3" json:"since,omitempty"`
+	Until         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=until,proto3" json:"until,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListHistoryRequest) Reset() {
+	*x = ListHistoryRequest{}
+	mi := &file_sslreminder_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHistoryRequest) ProtoMessage() {}
+
+func (x *ListHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sslreminder_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHistoryRequest.ProtoReflect.Descriptor instead.
+func (*ListHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_sslreminder_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListHistoryRequest) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *ListHistoryRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *ListHistoryRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+type ListHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*HostResult          `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListHistoryResponse) Reset() {
+	*x = ListHistoryResponse{}
+	mi := &file_sslreminder_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHistoryResponse) ProtoMessage() {}
+
+func (x *ListHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sslreminder_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHistoryResponse.ProtoReflect.Descriptor instead.
+func (*ListHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_sslreminder_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListHistoryResponse) GetResults() []*HostResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+var File_sslreminder_proto protoreflect.FileDescriptor
+
+const file_sslreminder_proto_rawDesc = "" +
+	"\n" +
+	"\x11sslreminder.proto\x12\vsslreminder\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb6\x05\n" +
+	"\n" +
+	"HostResult\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x125\n" +
+	"\x04tags\x18\x03 \x03(\v2!.sslreminder.HostResult.TagsEntryR\x04tags\x129\n" +
+	"\n" +
+	"checked_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcheckedAt\x12)\n" +
+	"\x10duration_seconds\x18\x05 \x01(\x01R\x0fdurationSeconds\x127\n" +
+	"\tnot_after\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bnotAfter\x12%\n" +
+	"\x0edays_remaining\x18\a \x01(\x01R\rdaysRemaining\x12\x12\n" +
+	"\x04tier\x18\b \x01(\tR\x04tier\x12\x16\n" +
+	"\x06issuer\x18\t \x01(\tR\x06issuer\x12\x1f\n" +
+	"\vchain_depth\x18\n" +
+	" \x01(\x05R\n" +
+	"chainDepth\x12\x1f\n" +
+	"\vtls_version\x18\v \x01(\tR\n" +
+	"tlsVersion\x12!\n" +
+	"\fcipher_suite\x18\f \x01(\tR\vcipherSuite\x12A\n" +
+	"\bfindings\x18\r \x03(\v2%.sslreminder.HostResult.FindingsEntryR\bfindings\x12\x14\n" +
+	"\x05error\x18\x0e \x01(\tR\x05error\x12\x1f\n" +
+	"\verror_class\x18\x0f \x01(\tR\n" +
+	"errorClass\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a;\n" +
+	"\rFindingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x12\n" +
+	"\x10GetStatusRequest\"\xc3\x02\n" +
+	"\x11GetStatusResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12H\n" +
+	"\x12last_cycle_started\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x10lastCycleStarted\x12J\n" +
+	"\x13last_cycle_finished\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x11lastCycleFinished\x129\n" +
+	"\n" +
+	"next_cycle\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tnextCycle\x12\x14\n" +
+	"\x05stale\x18\x05 \x01(\bR\x05stale\x12-\n" +
+	"\x05hosts\x18\x06 \x03(\v2\x17.sslreminder.HostResultR\x05hosts\"_\n" +
+	"\x10CheckHostRequest\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\x123\n" +
+	"\atimeout\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\atimeout\"\x8c\x01\n" +
+	"\x12ListHistoryRequest\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x120\n" +
+	"\x05since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\"H\n" +
+	"\x13ListHistoryResponse\x121\n" +
+	"\aresults\x18\x01 \x03(\v2\x17.sslreminder.HostResultR\aresults2\xf0\x01\n" +
+	"\vSSLReminder\x12J\n" +
+	"\tGetStatus\x12\x1d.sslreminder.GetStatusRequest\x1a\x1e.sslreminder.GetStatusResponse\x12C\n" +
+	"\tCheckHost\x12\x1d.sslreminder.CheckHostRequest\x1a\x17.sslreminder.HostResult\x12P\n" +
+	"\vListHistory\x12\x1f.sslreminder.ListHistoryRequest\x1a .sslreminder.ListHistoryResponseB%Z#github.com/tkawachi/sslreminder/apib\x06proto3"
+
+var (
+	file_sslreminder_proto_rawDescOnce sync.Once
+	file_sslreminder_proto_rawDescData []byte
+)
+
+func file_sslreminder_proto_rawDescGZIP() []byte {
+	file_sslreminder_proto_rawDescOnce.Do(func() {
+		file_sslreminder_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sslreminder_proto_rawDesc), len(file_sslreminder_proto_rawDesc)))
+	})
+	return file_sslreminder_proto_rawDescData
+}
+
+var file_sslreminder_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_sslreminder_proto_goTypes = []any{
+	(*HostResult)(nil),            // 0: sslreminder.HostResult
+	(*GetStatusRequest)(nil),      // 1: sslreminder.GetStatusRequest
+	(*GetStatusResponse)(nil),     // 2: sslreminder.GetStatusResponse
+	(*CheckHostRequest)(nil),      // 3: sslreminder.CheckHostRequest
+	(*ListHistoryRequest)(nil),    // 4: sslreminder.ListHistoryRequest
+	(*ListHistoryResponse)(nil),   // 5: sslreminder.ListHistoryResponse
+	nil,                           // 6: sslreminder.HostResult.TagsEntry
+	nil,                           // 7: sslreminder.HostResult.FindingsEntry
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 9: google.protobuf.Duration
+}
+var file_sslreminder_proto_depIdxs = []int32{
+	6,  // 0: sslreminder.HostResult.tags:type_name -> sslreminder.HostResult.TagsEntry
+	8,  // 1: sslreminder.HostResult.checked_at:type_name -> google.protobuf.Timestamp
+	8,  // 2: sslreminder.HostResult.not_after:type_name -> google.protobuf.Timestamp
+	7,  // 3: sslreminder.HostResult.findings:type_name -> sslreminder.HostResult.FindingsEntry
+	8,  // 4: sslreminder.GetStatusResponse.last_cycle_started:type_name -> google.protobuf.Timestamp
+	8,  // 5: sslreminder.GetStatusResponse.last_cycle_finished:type_name -> google.protobuf.Timestamp
+	8,  // 6: sslreminder.GetStatusResponse.next_cycle:type_name -> google.protobuf.Timestamp
+	0,  // 7: sslreminder.GetStatusResponse.hosts:type_name -> sslreminder.HostResult
+	9,  // 8: sslreminder.CheckHostRequest.timeout:type_name -> google.protobuf.Duration
+	8,  // 9: sslreminder.ListHistoryRequest.since:type_name -> google.protobuf.Timestamp
+	8,  // 10: sslreminder.ListHistoryRequest.until:type_name -> google.protobuf.Timestamp
+	0,  // 11: sslreminder.ListHistoryResponse.results:type_name -> sslreminder.HostResult
+	1,  // 12: sslreminder.SSLReminder.GetStatus:input_type -> sslreminder.GetStatusRequest
+	3,  // 13: sslreminder.SSLReminder.CheckHost:input_type -> sslreminder.CheckHostRequest
+	4,  // 14: sslreminder.SSLReminder.ListHistory:input_type -> sslreminder.ListHistoryRequest
+	2,  // 15: sslreminder.SSLReminder.GetStatus:output_type -> sslreminder.GetStatusResponse
+	0,  // 16: sslreminder.SSLReminder.CheckHost:output_type -> sslreminder.HostResult
+	5,  // 17: sslreminder.SSLReminder.ListHistory:output_type -> sslreminder.ListHistoryResponse
+	15, // [15:18] is the sub-list for method output_type
+	12, // [12:15] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_sslreminder_proto_init() }
+func file_sslreminder_proto_init() {
+	if File_sslreminder_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sslreminder_proto_rawDesc), len(file_sslreminder_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sslreminder_proto_goTypes,
+		DependencyIndexes: file_sslreminder_proto_depIdxs,
+		MessageInfos:      file_sslreminder_proto_msgTypes,
+	}.Build()
+	File_sslreminder_proto = out.File
+	file_sslreminder_proto_goTypes = nil
+	file_sslreminder_proto_depIdxs = nil
+}
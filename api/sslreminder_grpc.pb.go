@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v5.29.3
+// source: sslreminder.proto
+
+package api
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SSLReminder_GetStatus_FullMethodName   = "/sslreminder.SSLReminder/GetStatus"
+	SSLReminder_CheckHost_FullMethodName   = "/sslreminder.SSLReminder/CheckHost"
+	SSLReminder_ListHistory_FullMethodName = "/sslreminder.SSLReminder/ListHistory"
+)
+
+// SSLReminderClient is the client API for SSLReminder service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Status and on-demand checks of sslreminder.
+// Credentials of the HTTP server are given by the authorization metadata
+// like "Bearer <token>".
+type SSLReminderClient interface {
+	// The latest results of monitored hosts, like GET /status.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	// Check a host on demand, like GET /probe.
+	CheckHost(ctx context.Context, in *CheckHostRequest, opts ...grpc.CallOption) (*HostResult, error)
+	// Past checks of a host in HISTORY_FILE, oldest first, with days
+	// remaining as of each check. Unimplemented without HISTORY_FILE.
+	ListHistory(ctx context.Context, in *ListHistoryRequest, opts ...grpc.CallOption) (*ListHistoryResponse, error)
+}
+
+type sSLReminderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSSLReminderClient(cc grpc.ClientConnInterface) SSLReminderClient {
+	return &sSLReminderClient{cc}
+}
+
+func (c *sSLReminderClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatusResponse)
+	err := c.cc.Invoke(ctx, SSLReminder_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sSLReminderClient) CheckHost(ctx context.Context, in *CheckHostRequest, opts ...grpc.CallOption) (*HostResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HostResult)
+	err := c.cc.Invoke(ctx, SSLReminder_CheckHost_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sSLReminderClient) ListHistory(ctx context.Context, in *ListHistoryRequest, opts ...grpc.CallOption) (*ListHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListHistoryResponse)
+	err := c.cc.Invoke(ctx, SSLReminder_ListHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SSLReminderServer is the server API for SSLReminder service.
+// All implementations must embed UnimplementedSSLReminderServer
+// for forward compatibility.
+//
+// Status and on-demand checks of sslreminder.
+// Credentials of the HTTP server are given by the authorization metadata
+// like "Bearer <token>".
+type SSLReminderServer interface {
+	// The latest results of monitored hosts, like GET /status.
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	// Check a host on demand, like GET /probe.
+	CheckHost(context.Context, *CheckHostRequest) (*HostResult, error)
+	// Past checks of a host in HISTORY_FILE, oldest first, with days
+	// remaining as of each check. Unimplemented without HISTORY_FILE.
+	ListHistory(context.Context, *ListHistoryRequest) (*ListHistoryResponse, error)
+	mustEmbedUnimplementedSSLReminderServer()
+}
+
+// UnimplementedSSLReminderServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSSLReminderServer struct{}
+
+func (UnimplementedSSLReminderServer) GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedSSLReminderServer) CheckHost(context.Context, *CheckHostRequest) (*HostResult, error) {
+	return nil, status.Error(codes.Unimplemented, "method CheckHost not implemented")
+}
+func (UnimplementedSSLReminderServer) ListHistory(context.Context, *ListHistoryRequest) (*ListHistoryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListHistory not implemented")
+}
+func (UnimplementedSSLReminderServer) mustEmbedUnimplementedSSLReminderServer() {}
+func (UnimplementedSSLReminderServer) testEmbeddedByValue()                     {}
+
+// UnsafeSSLReminderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SSLReminderServer will
+// result in compilation errors.
+type UnsafeSSLReminderServer interface {
+	mustEmbedUnimplementedSSLReminderServer()
+}
+
+func RegisterSSLReminderServer(s grpc.ServiceRegistrar, srv SSLReminderServer) {
+	// If the following call panics, it indicates UnimplementedSSLReminderServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SSLReminder_ServiceDesc, srv)
+}
+
+func _SSLReminder_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SSLReminderServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SSLReminder_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SSLReminderServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SSLReminder_CheckHost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckHostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SSLReminderServer).CheckHost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SSLReminder_CheckHost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SSLReminderServer).CheckHost(ctx, req.(*CheckHostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SSLReminder_ListHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SSLReminderServer).ListHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SSLReminder_ListHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SSLReminderServer).ListHistory(ctx, req.(*ListHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SSLReminder_ServiceDesc is the grpc.ServiceDesc for SSLReminder service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SSLReminder_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sslreminder.SSLReminder",
+	HandlerType: (*SSLReminderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _SSLReminder_GetStatus_Handler,
+		},
+		{
+			MethodName: "CheckHost",
+			Handler:    _SSLReminder_CheckHost_Handler,
+		},
+		{
+			MethodName: "ListHistory",
+			Handler:    _SSLReminder_ListHistory_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sslreminder.proto",
+}
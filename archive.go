@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The layout of dates in names of archived certificates.
+const archiveDateLayout = "2006-01-02"
+
+// Archives certificates served by hosts as PEM files like
+// <dir>/<host>/<date>.pem.
+type certArchive struct {
+	dir string
+	// Whether to archive full chains rather than leaves.
+	chain bool
+	// How long to keep archived certificates. 0 to keep them forever.
+	retention time.Duration
+}
+
+func (a *certArchive) name() string {
+	return "ARCHIVE_CERTS " + a.dir
+}
+
+// Directory of a host, with ports separated by _ rather than :.
+func (a *certArchive) hostDir(host string) string {
+	return filepath.Join(a.dir, strings.Replace(host, ":", "_", -1))
+}
+
+// Archive certificates of hosts checked in the cycle, and remove ones
+// older than the retention. Failures of hosts are logged without
+// affecting others.
+func (a *certArchive) publish(res *cycleResult) error {
+	failed := 0
+	for _, c := range res.checks {
+		if c.result == nil || len(c.result.chain) == 0 {
+			continue
+		}
+		if err := a.write(c, res.now); err != nil {
+			log.Printf("ERROR archiving certificate of %v: %v", c.target.host, err)
+			failed++
+		}
+	}
+	if a.retention > 0 {
+		a.prune(res.now)
+	}
+	if failed > 0 {
+		return fmt.Errorf("Failed to archive %d certificates", failed)
+	}
+	return nil
+}
+
+// Write the certificate of a check, replacing one of the same date.
+func (a *certArchive) write(c *hostCheck, now time.Time) error {
+	certs := c.result.chain
+	if !a.chain {
+		certs = certs[:1]
+	}
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+			return err
+		}
+	}
+	dir := a.hostDir(c.target.host)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, now.UTC().Format(archiveDateLayout)+".pem")
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// Remove archived certificates dated before the retention.
+func (a *certArchive) prune(now time.Time) {
+	hostDirs, err := ioutil.ReadDir(a.dir)
+	if err != nil {
+		log.Printf("ERROR reading %v: %v", a.dir, err)
+		return
+	}
+	oldest := now.Add(-a.retention)
+	for _, hostDir := range hostDirs {
+		if !hostDir.IsDir() {
+			continue
+		}
+		dir := filepath.Join(a.dir, hostDir.Name())
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			log.Printf("ERROR reading %v: %v", dir, err)
+			continue
+		}
+		for _, f := range files {
+			date, err := time.Parse(archiveDateLayout, strings.TrimSuffix(f.Name(), ".pem"))
+			if err != nil || !strings.HasSuffix(f.Name(), ".pem") {
+				continue
+			}
+			if date.AddDate(0, 0, 1).After(oldest) {
+				continue
+			}
+			path := filepath.Join(dir, f.Name())
+			if err := os.Remove(path); err != nil {
+				log.Printf("ERROR removing %v: %v", path, err)
+			}
+		}
+	}
+}
+
+// Read ARCHIVE_CERTS, ARCHIVE_CHAIN and ARCHIVE_RETENTION_DAYS.
+// Nil unless ARCHIVE_CERTS is set.
+func readCertArchive() *certArchive {
+	dir := envOptional("ARCHIVE_CERTS", "")
+	if dir == "" {
+		return nil
+	}
+	days := envOptionalInt("ARCHIVE_RETENTION_DAYS", 90)
+	if days < 0 {
+		log.Fatalf("ARCHIVE_RETENTION_DAYS must not be negative: %v", days)
+	}
+	return &certArchive{
+		dir:       dir,
+		chain:     envOptionalBool("ARCHIVE_CHAIN", false),
+		retention: time.Duration(days) * 24 * time.Hour,
+	}
+}
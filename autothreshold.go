@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Days for certificates issued by issuers containing a name, like
+// thresholds of ISSUER_THRESHOLDS and lifetimes of ISSUER_LIFETIMES.
+type issuerDays struct {
+	issuer string
+	days   int
+}
+
+// Read comma separated days by issuers like "Let's Encrypt=20,DigiCert=60"
+// in an environment variable, each at least min.
+// Exit process if it's malformed.
+func readIssuerDays(name string, min int) []issuerDays {
+	var issuers []issuerDays
+	for _, spec := range splitOptional(envOptional(name, "")) {
+		i := strings.LastIndex(spec, "=")
+		if i <= 0 {
+			log.Fatalf("Invalid %v: %q", name, spec)
+		}
+		days, err := strconv.Atoi(spec[i+1:])
+		if err != nil || days < min {
+			log.Fatalf("Invalid days of %v: %q", name, spec)
+		}
+		issuers = append(issuers, issuerDays{spec[:i], days})
+	}
+	return issuers
+}
+
+// Read ISSUER_THRESHOLDS like "Let's Encrypt=20,DigiCert=60".
+// Exit process if it's malformed.
+func readIssuerThresholds() []issuerDays {
+	return readIssuerDays("ISSUER_THRESHOLDS", 0)
+}
+
+// The threshold of a host serving r, and why it applies.
+// The days by thresholdDaysOf are raised to THRESHOLD_PERCENT of the
+// validity period if it's earlier.
+// r may be nil if the host has no result.
+func (config *config) thresholdOf(host string, r *result) (int, string) {
+	days, reason := config.thresholdDaysOf(host, r)
+	if config.thresholdPercent <= 0 || r == nil || r.notBefore.IsZero() {
+		return days, reason
+	}
+	lifetime := secondsBetween(r.notBefore, r.notAfter) / (24 * 60 * 60)
+	if percentDays := int(math.Ceil(lifetime * float64(config.thresholdPercent) / 100)); percentDays > days {
+		return percentDays, fmt.Sprintf("THRESHOLD_PERCENT %v%% of %.0f days of validity",
+			config.thresholdPercent, lifetime)
+	}
+	return days, reason
+}
+
+// Seconds from t to u in whole seconds, which unlike time.Duration don't
+// saturate at about 292 years for implausibly long validity periods.
+func secondsBetween(t, u time.Time) float64 {
+	return float64(u.Unix() - t.Unix())
+}
+
+// Percentage of the validity period of r remaining at now,
+// clamped to 0 and 100. Nil without the validity period.
+func remainingPercent(r *result, now time.Time) *float64 {
+	lifetime := secondsBetween(r.notBefore, r.notAfter)
+	if r.notBefore.IsZero() || lifetime <= 0 {
+		return nil
+	}
+	percent := math.Max(0, math.Min(100, 100*secondsBetween(now, r.notAfter)/lifetime))
+	return &percent
+}
+
+// The threshold days of a host serving r, and why it applies.
+// Thresholds by HOSTS_DB_QUERY come first, then THRESHOLD_RULES, then
+// ISSUER_THRESHOLDS, then THRESHOLD_LIFETIME_PERCENT of the validity
+// period, then THRESHOLD_DAYS.
+func (config *config) thresholdDaysOf(host string, r *result) (int, string) {
+	if days, ok := config.database.threshold(host); ok {
+		return days, "database"
+	}
+	if rule := config.ruleOf(host); rule != nil {
+		return rule.days, fmt.Sprintf("rule %v", rule.pattern)
+	}
+	if r == nil {
+		return config.thresholdDays, "THRESHOLD_DAYS"
+	}
+	if len(r.chain) > 0 {
+		issuer := r.chain[0].Issuer.String()
+		for _, it := range config.issuerThresholds {
+			if strings.Contains(issuer, it.issuer) {
+				return it.days, fmt.Sprintf("issuer %v", it.issuer)
+			}
+		}
+	}
+	if config.lifetimePercent > 0 && !r.notBefore.IsZero() {
+		lifetime := secondsBetween(r.notBefore, r.notAfter) / (24 * 60 * 60)
+		days := int(lifetime * float64(config.lifetimePercent) / 100)
+		if days < config.lifetimeMinDays {
+			days = config.lifetimeMinDays
+		}
+		if config.lifetimeMaxDays > 0 && days > config.lifetimeMaxDays {
+			days = config.lifetimeMaxDays
+		}
+		return days, fmt.Sprintf("%v%% of %.0f days of validity", config.lifetimePercent, lifetime)
+	}
+	return config.thresholdDays, "THRESHOLD_DAYS"
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// A result valid for days from now.
+func validFor(now time.Time, days int) *result {
+	return &result{notBefore: now, notAfter: now.AddDate(0, 0, days)}
+}
+
+func TestThresholdPercent(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name          string
+		thresholdDays int
+		percent       int
+		validity      int
+		want          int
+	}{
+		// 25% of 90 days is 22.5 days, earlier than 30 days.
+		{"short validity", 30, 25, 90, 30},
+		// 25% of 398 days is 99.5 days, rounded up.
+		{"long validity", 30, 25, 398, 100},
+		{"percentage only", 0, 25, 90, 23},
+		{"disabled", 30, 0, 398, 30},
+	}
+	for _, c := range cases {
+		config := &config{thresholdDays: c.thresholdDays, thresholdPercent: c.percent}
+		if got, _ := config.thresholdOf("www.example.com", validFor(now, c.validity)); got != c.want {
+			t.Errorf("%v: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestThresholdPercentWithoutValidity(t *testing.T) {
+	config := &config{thresholdDays: 30, thresholdPercent: 25}
+	if got, _ := config.thresholdOf("www.example.com", nil); got != 30 {
+		t.Errorf("without result: got %v, want 30", got)
+	}
+	r := &result{notAfter: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if got, _ := config.thresholdOf("www.example.com", r); got != 30 {
+		t.Errorf("without notBefore: got %v, want 30", got)
+	}
+}
+
+func TestThresholdLifetimePercent(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		validity int
+		want     int
+	}{
+		// 33% of 90 days is 29.7 days, rounded down.
+		{"short validity", 90, 29},
+		// 33% of 10 days is clamped to THRESHOLD_MIN_DAYS.
+		{"very short validity", 10, 7},
+		// 33% of 398 days is clamped to THRESHOLD_MAX_DAYS.
+		{"long validity", 398, 60},
+	}
+	for _, c := range cases {
+		config := &config{thresholdDays: 30, lifetimePercent: 33,
+			lifetimeMinDays: 7, lifetimeMaxDays: 60}
+		if got, _ := config.thresholdOf("www.example.com", validFor(now, c.validity)); got != c.want {
+			t.Errorf("%v: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestRemainingPercent(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := &result{notBefore: now.AddDate(0, 0, -60), notAfter: now.AddDate(0, 0, 30)}
+	if got := remainingPercent(r, now); got == nil || *got < 33.3 || *got > 33.4 {
+		t.Errorf("got %v, want a third", got)
+	}
+	if got := remainingPercent(r, now.AddDate(0, 0, 31)); got == nil || *got != 0 {
+		t.Errorf("expired: got %v, want 0", got)
+	}
+	if got := remainingPercent(&result{notAfter: now}, now); got != nil {
+		t.Errorf("without notBefore: got %v, want nil", *got)
+	}
+}
+
+func TestRemainingPercentOfLongValidity(t *testing.T) {
+	// Longer than time.Duration can hold.
+	notBefore := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := &result{notBefore: notBefore, notAfter: notBefore.AddDate(1000, 0, 0)}
+	now := notBefore.AddDate(500, 0, 0)
+	if got := remainingPercent(r, now); got == nil || *got < 49.9 || *got > 50.1 {
+		t.Errorf("got %v, want half", got)
+	}
+}
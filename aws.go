@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"log"
+	"strings"
+)
+
+// Retries of throttled or failed AWS API calls, with the SDK's backoff.
+const awsMaxRetries = 10
+
+// Discovers records in Route 53 public hosted zones.
+type route53Source struct {
+	client   *route53.Route53
+	suffixes []string
+	dryRun   bool
+}
+
+// Discovers HTTPS/TLS listeners of application and network load balancers.
+type elbSource struct {
+	client *elbv2.ELBV2
+	dryRun bool
+}
+
+// Read AWS discovery configs.
+// Credentials and region are taken from the SDK's usual chain,
+// e.g. AWS_ACCESS_KEY_ID, ~/.aws/credentials or an instance role.
+func readAWSSources() []source {
+	useRoute53 := envOptionalBool("AWS_ROUTE53", false)
+	useELB := envOptionalBool("AWS_ELB", false)
+	if !useRoute53 && !useELB {
+		return nil
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *aws.NewConfig().WithMaxRetries(awsMaxRetries),
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create AWS session: %v", err)
+	}
+	dryRun := envOptionalBool("AWS_DRY_RUN", false)
+
+	var sources []source
+	if useRoute53 {
+		var suffixes []string
+		if s := envOptional("AWS_ROUTE53_SUFFIXES", ""); len(s) > 0 {
+			suffixes = strings.Split(s, ",")
+		}
+		sources = append(sources,
+			&route53Source{route53.New(sess), suffixes, dryRun})
+	}
+	if useELB {
+		sources = append(sources, &elbSource{elbv2.New(sess), dryRun})
+	}
+	return sources
+}
+
+// Log targets instead of returning them in dry-run mode.
+func awsDryRun(src source, targets []target) []target {
+	for _, t := range targets {
+		log.Printf("Would monitor %v (discovered via %v)", t.host, t.source)
+	}
+	log.Printf("%v discovered %v hosts, not checked in dry-run mode",
+		src.name(), len(targets))
+	return nil
+}
+
+func (r *route53Source) name() string {
+	return "Route 53"
+}
+
+// Whether a record name matches the suffix allowlist.
+// Suffixes match whole labels, so example.com matches www.example.com but
+// not badexample.com.
+func (r *route53Source) allowed(name string) bool {
+	if len(r.suffixes) == 0 {
+		return true
+	}
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for _, suffix := range r.suffixes {
+		suffix = strings.ToLower(strings.Trim(strings.TrimSpace(suffix), "."))
+		if name == suffix || strings.HasSuffix(name, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *route53Source) discover() ([]target, error) {
+	var zones []*route53.HostedZone
+	err := r.client.ListHostedZonesPages(&route53.ListHostedZonesInput{},
+		func(out *route53.ListHostedZonesOutput, last bool) bool {
+			zones = append(zones, out.HostedZones...)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("listing hosted zones: %v", err)
+	}
+
+	var targets []target
+	for _, zone := range zones {
+		if zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone) {
+			continue
+		}
+		zoneName := strings.TrimSuffix(aws.StringValue(zone.Name), ".")
+		input := &route53.ListResourceRecordSetsInput{HostedZoneId: zone.Id}
+		err := r.client.ListResourceRecordSetsPages(input,
+			func(out *route53.ListResourceRecordSetsOutput, last bool) bool {
+				for _, rrs := range out.ResourceRecordSets {
+					switch aws.StringValue(rrs.Type) {
+					case "A", "AAAA", "CNAME":
+					default:
+						continue
+					}
+					name := strings.TrimSuffix(aws.StringValue(rrs.Name), ".")
+					// Route 53 escapes "*" as "\052".
+					if strings.Contains(name, `\052`) || !r.allowed(name) {
+						continue
+					}
+					targets = append(targets, target{
+						host:   name,
+						source: fmt.Sprintf("Route 53 zone %v", zoneName),
+					})
+				}
+				return true
+			})
+		if err != nil {
+			return nil, fmt.Errorf("listing records of %v: %v", zoneName, err)
+		}
+	}
+
+	if r.dryRun {
+		return awsDryRun(r, targets), nil
+	}
+	return targets, nil
+}
+
+func (e *elbSource) name() string {
+	return "ELB"
+}
+
+func (e *elbSource) discover() ([]target, error) {
+	var lbs []*elbv2.LoadBalancer
+	err := e.client.DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{},
+		func(out *elbv2.DescribeLoadBalancersOutput, last bool) bool {
+			lbs = append(lbs, out.LoadBalancers...)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("describing load balancers: %v", err)
+	}
+
+	var targets []target
+	for _, lb := range lbs {
+		lbName := aws.StringValue(lb.LoadBalancerName)
+		input := &elbv2.DescribeListenersInput{LoadBalancerArn: lb.LoadBalancerArn}
+		err := e.client.DescribeListenersPages(input,
+			func(out *elbv2.DescribeListenersOutput, last bool) bool {
+				for _, l := range out.Listeners {
+					switch aws.StringValue(l.Protocol) {
+					case "HTTPS", "TLS":
+					default:
+						continue
+					}
+					targets = append(targets, target{
+						host: fmt.Sprintf("%v:%v",
+							aws.StringValue(lb.DNSName), aws.Int64Value(l.Port)),
+						source:     fmt.Sprintf("load balancer %v", lbName),
+						ignoreName: true,
+					})
+				}
+				return true
+			})
+		if err != nil {
+			return nil, fmt.Errorf("describing listeners of %v: %v", lbName, err)
+		}
+	}
+
+	if e.dryRun {
+		return awsDryRun(e, targets), nil
+	}
+	return targets, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestRoute53Allowed(t *testing.T) {
+	r := &route53Source{suffixes: []string{"example.com", "prod.example.net."}}
+	cases := []struct {
+		name string
+		want bool
+	}{
+		{"example.com", true},
+		{"www.example.com", true},
+		{"www.example.com.", true},
+		{"WWW.Example.COM", true},
+		{"badexample.com", false},
+		{"www.badexample.com", false},
+		{"example.com.evil.org", false},
+		{"api.prod.example.net", true},
+		{"api.preprod.example.net", false},
+	}
+	for _, c := range cases {
+		if got := r.allowed(c.name); got != c.want {
+			t.Errorf("allowed(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+	if !(&route53Source{}).allowed("badexample.com") {
+		t.Errorf("not allowed without AWS_ROUTE53_SUFFIXES")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Colors of badges by tiers.
+const (
+	badgeGreen  = "#4c1"
+	badgeYellow = "#dfb317"
+	badgeRed    = "#e05d44"
+	badgeGrey   = "#9f9f9f"
+)
+
+// How long clients and proxies may cache badges.
+const badgeMaxAge = 5 * time.Minute
+
+var badgeTemplate = template.Must(template.New("badge").Parse(
+	`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Message}}">` +
+		`<title>{{.Label}}: {{.Message}}</title>` +
+		`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` +
+		`<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>` +
+		`<g clip-path="url(#r)">` +
+		`<rect width="{{.LabelWidth}}" height="20" fill="#555"/>` +
+		`<rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/>` +
+		`<rect width="{{.Width}}" height="20" fill="url(#s)"/></g>` +
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` +
+		`<text x="{{.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{.Label}}</text>` +
+		`<text x="{{.LabelX}}" y="14">{{.Label}}</text>` +
+		`<text x="{{.MessageX}}" y="15" fill="#010101" fill-opacity=".3">{{.Message}}</text>` +
+		`<text x="{{.MessageX}}" y="14">{{.Message}}</text></g></svg>`))
+
+// A badge to be rendered.
+type badge struct {
+	Label        string
+	Message      string
+	Color        string
+	LabelWidth   int
+	MessageWidth int
+	Width        int
+	LabelX       float64
+	MessageX     float64
+}
+
+// Estimate the width in pixels of text in 11px Verdana.
+func textWidth(text string) int {
+	width := 0.0
+	for _, c := range text {
+		switch {
+		case strings.ContainsRune("ijlI.,:;'|!", c):
+			width += 3.5
+		case strings.ContainsRune("frt()[] -", c):
+			width += 4.5
+		case strings.ContainsRune("mwMW%", c):
+			width += 10.5
+		case c >= 'A' && c <= 'Z':
+			width += 7.5
+		default:
+			width += 7
+		}
+	}
+	return int(math.Ceil(width))
+}
+
+func newBadge(label, message, color string) *badge {
+	const padding = 10
+	b := &badge{
+		Label:        label,
+		Message:      message,
+		Color:        color,
+		LabelWidth:   textWidth(label) + padding,
+		MessageWidth: textWidth(message) + padding,
+	}
+	b.Width = b.LabelWidth + b.MessageWidth
+	b.LabelX = float64(b.LabelWidth) / 2
+	b.MessageX = float64(b.LabelWidth) + float64(b.MessageWidth)/2
+	return b
+}
+
+// A badge of the latest check of a host.
+func hostBadge(config *config, c *hostCheck, now time.Time) *badge {
+	if c.result == nil {
+		return newBadge("ssl", "error", badgeGrey)
+	}
+	days := int(math.Floor(secondsBetween(now, c.result.notAfter) / (24 * 60 * 60)))
+	switch config.tierOf(c.target.host, c.result, now) {
+	case tierExpired:
+		return newBadge("ssl", "expired", badgeRed)
+	case tierCritical:
+		return newBadge("ssl", fmt.Sprintf("%v days", days), badgeRed)
+	case tierWarning:
+		return newBadge("ssl", fmt.Sprintf("%v days", days), badgeYellow)
+	default:
+		return newBadge("ssl", fmt.Sprintf("%v days", days), badgeGreen)
+	}
+}
+
+// Get the latest check of a host, or nil if it's not monitored.
+func (s *cycleStatus) lastCheck(host string) *hostCheck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hosts[host]
+}
+
+// Serve badges like GET /badge/shop.example.com.svg.
+// Unknown hosts get a grey badge with 404.
+func badgeHandler(config *config, status *cycleStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/badge/")
+		host := strings.TrimSuffix(name, ".svg")
+
+		code := http.StatusOK
+		var b *badge
+		if c := status.lastCheck(host); c != nil && host != name {
+			b = hostBadge(config, c, time.Now())
+		} else {
+			code = http.StatusNotFound
+			b = newBadge("ssl", "unknown host", badgeGrey)
+		}
+
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Cache-Control",
+			fmt.Sprintf("public, max-age=%v", int(badgeMaxAge.Seconds())))
+		w.WriteHeader(code)
+		if err := badgeTemplate.Execute(w, b); err != nil {
+			log.Printf("ERROR rendering badge: %v", err)
+		}
+	}
+}
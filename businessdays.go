@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Values of THRESHOLD_MODE.
+const (
+	thresholdCalendarDays = "calendar_days"
+	thresholdBusinessDays = "business_days"
+)
+
+// Read THRESHOLD_MODE, returning whether thresholds count business days.
+// Exit process if it's unknown.
+func readBusinessDays() bool {
+	switch mode := envOptional("THRESHOLD_MODE", thresholdCalendarDays); mode {
+	case thresholdCalendarDays:
+		return false
+	case thresholdBusinessDays:
+		return true
+	default:
+		log.Fatalf("Unknown THRESHOLD_MODE %q", mode)
+		return false
+	}
+}
+
+// Read dates like 2024-12-31 in the HOLIDAYS file, one per line.
+// Empty lines and lines starting with # are ignored.
+// Exit process if it's malformed.
+func readHolidays() map[string]bool {
+	path := envOptional("HOLIDAYS", "")
+	if len(path) == 0 {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to read HOLIDAYS: %v", err)
+	}
+	defer f.Close()
+
+	holidays := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", line); err != nil {
+			log.Fatalf("Invalid date in HOLIDAYS: %q", line)
+		}
+		holidays[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Failed to read HOLIDAYS: %v", err)
+	}
+	return holidays
+}
+
+// Whether a date is neither Saturday nor Sunday.
+func isWeekday(day time.Time) bool {
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+// The date of t in TIMEZONE, as midnight in UTC so that days between
+// dates are exactly 24 hours regardless of daylight saving time.
+func (config *config) dateOf(t time.Time) time.Time {
+	t = t.In(config.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Count business days from the day of now to the day before t in TIMEZONE.
+// Whole weeks have 5 weekdays each, so only the leftover days and
+// HOLIDAYS are looped over.
+func (config *config) countBusinessDays(now, t time.Time) int {
+	start, end := config.dateOf(now), config.dateOf(t)
+	if !start.Before(end) {
+		return 0
+	}
+	days := int(end.Sub(start).Hours() / 24)
+	n := days / 7 * 5
+	for day := start.AddDate(0, 0, days/7*7); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if isWeekday(day) {
+			n++
+		}
+	}
+	for holiday := range config.holidays {
+		day, err := time.Parse("2006-01-02", holiday)
+		if err == nil && !day.Before(start) && day.Before(end) && isWeekday(day) {
+			n--
+		}
+	}
+	return n
+}
+
+// Business days left before a certificate expires on notAfter,
+// counting today if it's a business day.
+func (config *config) businessDaysRemaining(notAfter, now time.Time) int {
+	return config.countBusinessDays(now, notAfter)
+}
+
+// Whether notAfter is within days from now, counting business days
+// if THRESHOLD_MODE is business_days.
+func (config *config) within(notAfter, now time.Time, days int) bool {
+	if !config.businessDays {
+		return notAfter.Before(now.AddDate(0, 0, days))
+	}
+	return notAfter.Before(now) || config.countBusinessDays(now, notAfter) < days
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBusinessDaysRemaining(t *testing.T) {
+	// 2024-01-01 is Monday.
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name     string
+		now      time.Time
+		notAfter time.Time
+		holidays []string
+		want     int
+	}{
+		{"a week", day(1), day(8), nil, 5},
+		{"expiring on Saturday", day(1), day(6), nil, 5},
+		{"expiring on Sunday", day(1), day(7), nil, 5},
+		{"from Saturday to Monday", day(6), day(8), nil, 0},
+		{"from Friday to Monday", day(5), day(8), nil, 1},
+		{"same day", day(3), day(3), nil, 0},
+		{"expired", day(8), day(1), nil, 0},
+		{"weeks and leftover days", day(1), day(24), nil, 17},
+		{"holiday on a weekday", day(1), day(8), []string{"2024-01-03"}, 4},
+		{"holiday on Saturday", day(1), day(8), []string{"2024-01-06"}, 5},
+		{"holiday on the expiration", day(1), day(8), []string{"2024-01-08"}, 5},
+		{"holiday before now", day(3), day(8), []string{"2024-01-02"}, 3},
+		{"holidays in later weeks", day(1), day(24),
+			[]string{"2024-01-08", "2024-01-17", "2024-01-20"}, 15},
+	}
+	for _, c := range cases {
+		config := &config{location: time.UTC, holidays: make(map[string]bool)}
+		for _, h := range c.holidays {
+			config.holidays[h] = true
+		}
+		if got := config.businessDaysRemaining(c.notAfter, c.now); got != c.want {
+			t.Errorf("%v: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestBusinessDaysRemainingInTimezone(t *testing.T) {
+	// Saturday 2024-01-06 in UTC is still Friday in Honolulu,
+	// and Monday 2024-01-08 01:00 in UTC is Sunday there.
+	config := &config{location: time.FixedZone("HST", -10*3600)}
+	now := time.Date(2024, 1, 6, 1, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2024, 1, 8, 1, 0, 0, 0, time.UTC)
+	if got := config.businessDaysRemaining(notAfter, now); got != 1 {
+		t.Errorf("got %v, want 1", got)
+	}
+}
+
+func TestBusinessDaysRemainingMatchesDayByDay(t *testing.T) {
+	config := &config{location: time.UTC, holidays: map[string]bool{
+		"2024-01-01": true, "2024-02-10": true, "2024-02-12": true,
+		"2024-05-03": true, "2024-12-25": true,
+	}}
+	start := time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC)
+	for from := 0; from < 14; from++ {
+		now := start.AddDate(0, 0, from)
+		for length := 0; length < 400; length += 3 {
+			notAfter := now.AddDate(0, 0, length)
+			want := 0
+			for day := now; day.Before(notAfter); day = day.AddDate(0, 0, 1) {
+				if isWeekday(day) && !config.holidays[day.Format("2006-01-02")] {
+					want++
+				}
+			}
+			if got := config.businessDaysRemaining(notAfter, now); got != want {
+				t.Errorf("from %v to %v: got %v, want %v",
+					now.Format("2006-01-02"), notAfter.Format("2006-01-02"), got, want)
+			}
+		}
+	}
+}
+
+func TestWithinBusinessDays(t *testing.T) {
+	config := &config{location: time.UTC, businessDays: true}
+	// Friday, expiring on Tuesday with 2 business days remaining.
+	now := time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2024, 1, 9, 12, 0, 0, 0, time.UTC)
+	if config.within(notAfter, now, 2) {
+		t.Errorf("within 2 business days")
+	}
+	if !config.within(notAfter, now, 3) {
+		t.Errorf("not within 3 business days")
+	}
+	if !config.within(now.Add(-time.Hour), now, 0) {
+		t.Errorf("expired certificate not within 0 days")
+	}
+}
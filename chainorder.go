@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+)
+
+// Whether parent issued child.
+func issuedBy(child, parent *x509.Certificate) bool {
+	return bytes.Equal(child.RawIssuer, parent.RawSubject) &&
+		child.CheckSignatureFrom(parent) == nil
+}
+
+// The ordering problem of a chain as served, or "" if it's in order.
+// The leaf must be first and each certificate must be followed by its
+// issuer. A chain missing its root, or intermediates after the last
+// certificate, is in order.
+func chainOrderProblem(certs []*x509.Certificate) string {
+	for i, cert := range certs[1:] {
+		if issuedBy(cert, certs[0]) {
+			return fmt.Sprintf("the leaf is not first; #1 %v issued #%v %v",
+				certs[0].Subject.CommonName, i+2, cert.Subject.CommonName)
+		}
+	}
+	for i := 0; i+1 < len(certs); i++ {
+		if issuedBy(certs[i], certs[i+1]) {
+			continue
+		}
+		for j, parent := range certs {
+			if j != i && issuedBy(certs[i], parent) {
+				return fmt.Sprintf("#%v %v is followed by #%v %v, not by its issuer #%v %v",
+					i+1, certs[i].Subject.CommonName, i+2, certs[i+1].Subject.CommonName,
+					j+1, parent.Subject.CommonName)
+			}
+		}
+		return fmt.Sprintf("#%v %v is followed by #%v %v, which didn't issue it",
+			i+1, certs[i].Subject.CommonName, i+2, certs[i+1].Subject.CommonName)
+	}
+	return ""
+}
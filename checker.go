@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// The name of this checker, to tell checkers at vantage points apart in
+// reminders, metrics and logs. Set by setupLog from CHECKER_NAME.
+var checkerName = ""
+
+// Read CHECKER_NAME, defaulting to the hostname.
+func readCheckerName() string {
+	if name := envOptional("CHECKER_NAME", ""); name != "" {
+		return name
+	}
+	name, err := os.Hostname()
+	if err != nil {
+		log.Printf("ERROR getting hostname for CHECKER_NAME: %v", err)
+		return ""
+	}
+	return name
+}
+
+// The footer of reminders naming the checker.
+func checkerText(rem *reminder) string {
+	if rem.Checker == "" {
+		return ""
+	}
+	return fmt.Sprintf("\nChecked by %v\n", rem.Checker)
+}
+
+// Add a label to every sample of metrics in the Prometheus text format.
+func labelMetrics(metrics []byte, name, value string) []byte {
+	var buf bytes.Buffer
+	label := fmt.Sprintf("%v=%q", name, value)
+	scanner := bufio.NewScanner(bytes.NewReader(metrics))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.Contains(line, "{"):
+			line = strings.Replace(line, "{", "{"+label+",", 1)
+		default:
+			line = strings.Replace(line, " ", "{"+label+"} ", 1)
+		}
+		buf.WriteString(line + "\n")
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// Check configured notifiers are reachable and authorized without
+// sending reminders, like "sslreminder check-notifiers", printing
+// pass or fail of each. Exits with 1 if any fails.
+func checkNotifiersCommand(args []string) {
+	if len(args) != 0 {
+		log.Fatalf("Usage: sslreminder check-notifiers")
+	}
+	config := readConfig()
+	failed := false
+	for _, n := range readNotifiers(config, readSendgridConfig()) {
+		p, ok := n.(pinger)
+		if !ok {
+			fmt.Printf("SKIP %v (%v): can't be checked\n", n.name(), n.recipients())
+			continue
+		}
+		if err := p.ping(); err != nil {
+			fmt.Printf("FAIL %v (%v): %v\n", n.name(), n.recipients(), err)
+			failed = true
+			continue
+		}
+		fmt.Printf("PASS %v (%v)\n", n.name(), n.recipients())
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
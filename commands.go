@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log"
+)
+
+// Run a command given by arguments like "mute www.example.com 48h".
+// Exit process if it's unknown.
+func runCommand(args []string) {
+	switch args[0] {
+	case "serve":
+		serve()
+	case "agent":
+		agentCommand(args[1:])
+	case "mute":
+		muteCommand(args[1:])
+	case "list":
+		listCommand(args[1:])
+	case "once":
+		onceCommand(args[1:])
+	case "ack":
+		ackCommand(args[1:])
+	case "state":
+		stateCommand(args[1:])
+	case "inventory":
+		inventoryCommand(args[1:])
+	case "check-notifiers":
+		checkNotifiersCommand(args[1:])
+	default:
+		log.Fatalf("Unknown command %q", args[0])
+	}
+}
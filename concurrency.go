@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"sync"
+)
+
+// Limits concurrent connections to each host regardless of ports,
+// so that checking many ports of a host doesn't overwhelm it.
+type hostSemaphores struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// Limits of connections to hosts by HOST_CONCURRENCY, set by readConfig.
+// Nil for no limits.
+var hostSlots *hostSemaphores
+
+// Nil if limit is not positive.
+func newHostSemaphores(limit int) *hostSemaphores {
+	if limit <= 0 {
+		return nil
+	}
+	return &hostSemaphores{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+// Wait for a slot of the hostname of a host like www.example.com:8443,
+// returning a function to release it.
+func (hs *hostSemaphores) acquire(host string) func() {
+	if hs == nil {
+		return func() {}
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	hs.mu.Lock()
+	slots, ok := hs.slots[host]
+	if !ok {
+		slots = make(chan struct{}, hs.limit)
+		hs.slots[host] = slots
+	}
+	hs.mu.Unlock()
+	slots <- struct{}{}
+	return func() { <-slots }
+}
+
+// Call fn with 0 to n-1, up to concurrency calls at a time.
+func forEachConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Values read from CONFIG_FILE, keyed by environmental variable names.
+// Environmental variables take precedence over them.
+var fileValues = map[string]string{}
+
+// Get a config value from environmental variables or CONFIG_FILE.
+func getenv(key string) string {
+	if value := os.Getenv(key); len(value) > 0 {
+		return value
+	}
+	return fileValues[key]
+}
+
+// Read CONFIG_FILE if it's set.
+// Its format is CONFIG_FORMAT, or detected by the extension of the file.
+// Exit process if it's malformed.
+func loadConfigFile() {
+	path := os.Getenv("CONFIG_FILE")
+	if len(path) == 0 {
+		return
+	}
+	format := os.Getenv("CONFIG_FORMAT")
+	if len(format) == 0 {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	var values map[string]string
+	var err error
+	switch strings.ToLower(format) {
+	case "toml":
+		values, err = readTOMLConfig(path)
+	default:
+		log.Fatalf("Unsupported format of CONFIG_FILE %v: %q", path, format)
+	}
+	if err != nil {
+		log.Fatalf("Failed to read CONFIG_FILE %v: %v", path, err)
+	}
+	fileValues = values
+}
+
+// Read a TOML config file.
+// Keys are environmental variable names in lower case, and tables are
+// prefixes of them, e.g. username in [sendgrid] for SENDGRID_USERNAME.
+// Hosts are an array of tables with host and tags.
+func readTOMLConfig(path string) (map[string]string, error) {
+	var doc map[string]interface{}
+	if _, err := toml.DecodeFile(path, &doc); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	if err := flattenConfig(values, "", doc); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Flatten a decoded config document into values keyed by
+// environmental variable names.
+func flattenConfig(values map[string]string, prefix string,
+	doc map[string]interface{}) error {
+	for key, value := range doc {
+		name := strings.ToUpper(prefix + key)
+		if name == "HOSTS" {
+			hosts, err := hostsValue(value)
+			if err != nil {
+				return err
+			}
+			values[name] = hosts
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flattenConfig(values, prefix+key+"_", v); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(items, ",")
+		case string, int64, float64, bool:
+			values[name] = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("Unsupported value of %v: %v", key, value)
+		}
+	}
+	return nil
+}
+
+// Convert hosts in a config file to the HOSTS format.
+// Hosts are either strings in the HOSTS format or tables like
+// {host = "www.example.com", tags = {team = "web"}}.
+func hostsValue(value interface{}) (string, error) {
+	var specs []string
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []interface{}:
+		for _, item := range v {
+			spec, ok := item.(string)
+			if !ok {
+				return "", fmt.Errorf("Invalid host: %v", item)
+			}
+			specs = append(specs, spec)
+		}
+	case []map[string]interface{}:
+		for _, item := range v {
+			spec, err := hostSpec(item)
+			if err != nil {
+				return "", err
+			}
+			specs = append(specs, spec)
+		}
+	default:
+		return "", fmt.Errorf("Invalid hosts: %v", value)
+	}
+	return strings.Join(specs, ","), nil
+}
+
+// Convert a host table in a config file to the HOSTS format.
+func hostSpec(item map[string]interface{}) (string, error) {
+	host, ok := item["host"].(string)
+	if !ok || len(host) == 0 {
+		return "", fmt.Errorf("Host without host name: %v", item)
+	}
+	tags, _ := item["tags"].(map[string]interface{})
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	spec := host
+	for _, key := range keys {
+		spec += fmt.Sprintf(";%v=%v", key, tags[key])
+	}
+	return spec, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Discovers hosts of services tagged in the Consul catalog.
+type consulSource struct {
+	client     *http.Client
+	baseURL    string
+	tag        string
+	token      string
+	datacenter string
+
+	mu sync.Mutex
+	// The last targets discovered successfully, reused on errors.
+	last []target
+}
+
+// An instance of a service in the Consul catalog API.
+type consulService struct {
+	Address        string
+	ServiceAddress string
+	ServicePort    int
+	ServiceMeta    map[string]string
+}
+
+// Read CONSUL_ADDR and related configs.
+// Returns nil if CONSUL_ADDR is not set.
+func readConsulSource() *consulSource {
+	addr := envOptional("CONSUL_ADDR", "")
+	if addr == "" {
+		return nil
+	}
+	return &consulSource{
+		client:     &http.Client{Timeout: 30 * time.Second},
+		baseURL:    strings.TrimSuffix(addr, "/"),
+		tag:        envOptional("CONSUL_TAG", "tls"),
+		token:      envOptional("CONSUL_TOKEN", ""),
+		datacenter: envOptional("CONSUL_DATACENTER", ""),
+	}
+}
+
+func (c *consulSource) name() string {
+	return "Consul"
+}
+
+// Targets of instances of services having the tag, or the last ones if
+// Consul fails.
+func (c *consulSource) discover() ([]target, error) {
+	targets, err := c.queryTargets()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		if c.last == nil {
+			return nil, err
+		}
+		log.Printf("ERROR querying Consul, reusing %v hosts of the last query: %v",
+			len(c.last), err)
+		return c.last, nil
+	}
+	c.last = targets
+	return targets, nil
+}
+
+// Get a path of the catalog API into out.
+func (c *consulSource) get(path string, query url.Values, out interface{}) error {
+	if c.datacenter != "" {
+		query.Set("dc", c.datacenter)
+	}
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status %v of %v", resp.Status, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Query instances of all services having the tag.
+// An instance is checked by its tls_host meta like api.example.com if
+// it has one, and otherwise by its address verifying only the chain.
+func (c *consulSource) queryTargets() ([]target, error) {
+	var services map[string][]string
+	if err := c.get("/v1/catalog/services", url.Values{}, &services); err != nil {
+		return nil, err
+	}
+	var names []string
+	for name, tags := range services {
+		for _, tag := range tags {
+			if tag == c.tag {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var targets []target
+	seen := make(map[string]bool)
+	for _, name := range names {
+		var instances []consulService
+		if err := c.get("/v1/catalog/service/"+url.PathEscape(name),
+			url.Values{"tag": {c.tag}}, &instances); err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			t := target{source: fmt.Sprintf("Consul service %v", name)}
+			if host := instance.ServiceMeta["tls_host"]; host != "" {
+				t.host = host
+			} else {
+				addr := instance.ServiceAddress
+				if addr == "" {
+					addr = instance.Address
+				}
+				t.host = net.JoinHostPort(addr, strconv.Itoa(instance.ServicePort))
+				t.ignoreName = true
+			}
+			if !seen[t.host] {
+				seen[t.host] = true
+				targets = append(targets, t)
+			}
+		}
+	}
+	return targets, nil
+}
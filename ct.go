@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Discovers subdomains of apex domains from Certificate Transparency logs
+// via a crt.sh compatible aggregator.
+type ctSource struct {
+	domains       []string
+	baseURL       string
+	maxHosts      int
+	dnsCheck      bool
+	cacheTTL      time.Duration
+	queryInterval time.Duration
+	client        *http.Client
+
+	mu        sync.Mutex
+	cache     map[string]ctCacheEntry
+	lastQuery time.Time
+}
+
+type ctCacheEntry struct {
+	hosts     []string
+	fetchedAt time.Time
+}
+
+// An entry of crt.sh JSON output.
+type ctEntry struct {
+	CommonName string `json:"common_name"`
+	NameValue  string `json:"name_value"`
+}
+
+// Read CT discovery configs.
+// Returns nil if CT_DOMAINS is not set.
+func readCTSource() *ctSource {
+	domains := envOptional("CT_DOMAINS", "")
+	if len(domains) == 0 {
+		return nil
+	}
+	return &ctSource{
+		domains:  strings.Split(domains, ","),
+		baseURL:  envOptional("CT_URL", "https://crt.sh/"),
+		maxHosts: envOptionalInt("CT_MAX_HOSTS", 100),
+		dnsCheck: envOptionalBool("CT_DNS_CHECK", true),
+		cacheTTL: time.Duration(envOptionalInt("CT_CACHE_HOURS", 24)) * time.Hour,
+		queryInterval: time.Duration(
+			envOptionalInt("CT_QUERY_INTERVAL_SECONDS", 5)) * time.Second,
+		client: &http.Client{Timeout: 60 * time.Second},
+		cache:  make(map[string]ctCacheEntry),
+	}
+}
+
+func (ct *ctSource) name() string {
+	return "CT"
+}
+
+// Discover hosts under all domains, up to maxHosts.
+// A domain failing to be queried doesn't prevent others from being used.
+func (ct *ctSource) discover() ([]target, error) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	var targets []target
+	var errs []string
+	for _, domain := range ct.domains {
+		hosts, err := ct.hosts(domain)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", domain, err))
+			continue
+		}
+		for _, host := range hosts {
+			if len(targets) >= ct.maxHosts {
+				log.Printf("Discovered hosts via CT are capped at %v",
+					ct.maxHosts)
+				return targets, nil
+			}
+			if ct.dnsCheck {
+				if _, err := net.LookupHost(host); err != nil {
+					log.Printf("Skipping %v discovered via CT: %v",
+						host, err)
+					continue
+				}
+			}
+			targets = append(targets, target{host: host, source: ct.name()})
+		}
+	}
+	if len(errs) > 0 && len(targets) == 0 {
+		return nil, fmt.Errorf("%v", strings.Join(errs, ", "))
+	}
+	for _, e := range errs {
+		log.Printf("ERROR querying CT for %v", e)
+	}
+	return targets, nil
+}
+
+// Get hosts under the domain, from the cache if it's fresh.
+// A stale cache is used when the aggregator fails.
+func (ct *ctSource) hosts(domain string) ([]string, error) {
+	cached, ok := ct.cache[domain]
+	if ok && time.Since(cached.fetchedAt) < ct.cacheTTL {
+		return cached.hosts, nil
+	}
+	hosts, err := ct.query(domain)
+	if err != nil {
+		if ok {
+			log.Printf("ERROR querying CT for %v, using cache: %v",
+				domain, err)
+			return cached.hosts, nil
+		}
+		return nil, err
+	}
+	ct.cache[domain] = ctCacheEntry{hosts, time.Now()}
+	log.Printf("Discovered %v hosts under %v via CT", len(hosts), domain)
+	return hosts, nil
+}
+
+// Query the aggregator for unexpired certificates under the domain.
+func (ct *ctSource) query(domain string) ([]string, error) {
+	if wait := ct.queryInterval - time.Since(ct.lastQuery); wait > 0 {
+		time.Sleep(wait)
+	}
+	ct.lastQuery = time.Now()
+
+	params := url.Values{}
+	params.Set("q", "%."+domain)
+	params.Set("output", "json")
+	params.Set("exclude", "expired")
+	resp, err := ct.client.Get(ct.baseURL + "?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %v", resp.Status)
+	}
+
+	var entries []ctEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return ctHosts(domain, entries), nil
+}
+
+// Extract hosts under the domain from CT entries.
+// Wildcards and duplicates are dropped.
+func ctHosts(domain string, entries []ctEntry) []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, e := range entries {
+		names := strings.Split(e.NameValue, "\n")
+		names = append(names, e.CommonName)
+		for _, name := range names {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" || strings.Contains(name, "*") || seen[name] {
+				continue
+			}
+			if name != domain && !strings.HasSuffix(name, "."+domain) {
+				continue
+			}
+			seen[name] = true
+			hosts = append(hosts, name)
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// Kinds of changes since the previous cycle, in addition to tier changes.
+const (
+	changeAdded     = "added"
+	changeRemoved   = "removed"
+	changeErrored   = "newly errored"
+	changeRecovered = "recovered"
+)
+
+// Results of a cycle persisted in CYCLE_FILE.
+type cycleSnapshot struct {
+	FinishedAt time.Time               `json:"finished_at"`
+	Hosts      map[string]hostSnapshot `json:"hosts"`
+}
+
+// A result of a host in a cycle.
+type hostSnapshot struct {
+	NotAfter *time.Time `json:"not_after,omitempty"`
+	Tier     tier       `json:"tier"`
+	Error    string     `json:"error,omitempty"`
+	// A new expiration observed in the last cycles in a row, which is not
+	// confirmed until RENEWAL_CONFIRMATIONS cycles.
+	Unconfirmed *time.Time `json:"unconfirmed_not_after,omitempty"`
+	Observed    int        `json:"observed,omitempty"`
+	// When the host was first seen, for NEW_HOST_GRACE.
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+}
+
+// Build a snapshot of checks since the previous one, which may be nil.
+func newCycleSnapshot(config *config, prev *cycleSnapshot, now time.Time,
+	checks []*hostCheck) *cycleSnapshot {
+	s := &cycleSnapshot{FinishedAt: now, Hosts: make(map[string]hostSnapshot)}
+	for _, c := range checks {
+		var h hostSnapshot
+		if c.err != nil {
+			h.Error = c.err.Error()
+		} else {
+			notAfter := c.result.notAfter
+			h.NotAfter = &notAfter
+			h.Tier = config.tierOf(c.target.host, c.result, now)
+		}
+		if !c.target.firstSeen.IsZero() {
+			firstSeen := c.target.firstSeen
+			h.FirstSeen = &firstSeen
+		}
+		s.Hosts[c.target.host] = h
+	}
+	if prev != nil {
+		config.confirmChanges(prev, s)
+	}
+	return s
+}
+
+// Keep previous expirations in a snapshot until their changes are
+// observed in RENEWAL_CONFIRMATIONS cycles in a row, so that a wrong
+// certificate served once isn't taken for a renewal.
+func (config *config) confirmChanges(prev, s *cycleSnapshot) {
+	if config.renewalConfirmations <= 1 {
+		return
+	}
+	for host, h := range s.Hosts {
+		before, ok := prev.Hosts[host]
+		if !ok || h.NotAfter == nil || before.NotAfter == nil ||
+			h.NotAfter.Equal(*before.NotAfter) {
+			continue
+		}
+		observed := before.observedChange(*h.NotAfter)
+		if observed >= config.renewalConfirmations {
+			continue
+		}
+		log.Printf("Change of expiration of %v to %v observed %v of %v times",
+			host, *h.NotAfter, observed, config.renewalConfirmations)
+		h.Unconfirmed, h.Observed = h.NotAfter, observed
+		h.NotAfter, h.Tier = before.NotAfter, before.Tier
+		s.Hosts[host] = h
+	}
+}
+
+// Cycles in a row a changed expiration has been observed in, counting
+// the current cycle after the host was h in the previous one.
+func (h hostSnapshot) observedChange(notAfter time.Time) int {
+	if h.Unconfirmed != nil && h.Unconfirmed.Equal(notAfter) {
+		return h.Observed + 1
+	}
+	return 1
+}
+
+// Results of hosts without changes of expirations not confirmed by
+// RENEWAL_CONFIRMATIONS since prevCycle, which may be nil, so that
+// STATE_FILE and changes in reminders treat them like CYCLE_FILE does.
+func (config *config) confirmedResults(prevCycle *cycleSnapshot,
+	exMap map[string]*result) map[string]*result {
+	if config.renewalConfirmations <= 1 || prevCycle == nil {
+		return exMap
+	}
+	confirmed := make(map[string]*result, len(exMap))
+	for host, r := range exMap {
+		before, ok := prevCycle.Hosts[host]
+		if ok && before.NotAfter != nil && !r.notAfter.Equal(*before.NotAfter) &&
+			before.observedChange(r.notAfter) < config.renewalConfirmations {
+			continue
+		}
+		confirmed[host] = r
+	}
+	return confirmed
+}
+
+// Read a snapshot from CYCLE_FILE.
+// Returns nil without error if the file doesn't exist yet.
+func loadCycleSnapshot(path string) (*cycleSnapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s cycleSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", path, err)
+	}
+	return &s, nil
+}
+
+// Persists results of each cycle to CYCLE_FILE.
+type cycleFileSink struct {
+	config *config
+	path   string
+}
+
+func (s *cycleFileSink) name() string {
+	return "cycle file"
+}
+
+// Write a snapshot of all checks of a cycle atomically.
+func (s *cycleFileSink) publish(res *cycleResult) error {
+	checks := append(append([]*hostCheck(nil), res.checks...), res.carried...)
+	prev, err := loadCycleSnapshot(s.path)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(newCycleSnapshot(s.config, prev, res.now, checks), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// Changes of a snapshot since the previous one, sorted by hosts.
+func diffCycles(prev, current *cycleSnapshot) []change {
+	var changes []change
+	for host, h := range current.Hosts {
+		before, ok := prev.Hosts[host]
+		switch {
+		case !ok:
+			changes = append(changes, change{host, changeAdded, ""})
+		case h.Error != "" && before.Error == "":
+			changes = append(changes, change{host, changeErrored, h.Error})
+		case h.Error == "" && before.Error != "":
+			changes = append(changes, change{host, changeRecovered, h.Tier.String()})
+		case h.Error != "":
+		case h.NotAfter.After(*before.NotAfter):
+			changes = append(changes, change{host, changeRenewed,
+				fmt.Sprintf("%v -> %v", *before.NotAfter, *h.NotAfter)})
+		case h.Tier != before.Tier:
+			changes = append(changes, change{host, changeTier,
+				fmt.Sprintf("%v -> %v", before.Tier, h.Tier)})
+		}
+	}
+	for host := range prev.Hosts {
+		if _, ok := current.Hosts[host]; !ok {
+			changes = append(changes, change{host, changeRemoved, ""})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].host < changes[j].host
+	})
+	return changes
+}
+
+// The section of changes since the previous cycle in mails.
+// Empty if there's no previous cycle.
+func cycleChangesText(rem *reminder) string {
+	if rem.prevCycle == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("Changes since last check at %v:\n",
+		rem.prevCycle.FinishedAt))
+	if len(rem.CycleChanges) == 0 {
+		buf.WriteString("None\n")
+	}
+	for _, c := range rem.CycleChanges {
+		if c.Detail != "" {
+			buf.WriteString(fmt.Sprintf("%v: %v (%v)\n", c.Host, c.Kind, c.Detail))
+		} else {
+			buf.WriteString(fmt.Sprintf("%v: %v\n", c.Host, c.Kind))
+		}
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
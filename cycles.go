@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A request of a check cycle.
+type cycleRequest struct {
+	id int
+	// Hosts to check. Nil for all hosts.
+	hosts map[string]bool
+	// Check hosts regardless of their schedules.
+	force bool
+}
+
+// Whether a host is checked in the cycle.
+func (req *cycleRequest) includes(host string) bool {
+	return req.hosts == nil || req.hosts[host]
+}
+
+// Runs check cycles one at a time.
+// A cycle requested while another runs waits for it, and further
+// requests meanwhile collapse into the waiting one.
+type cycleRunner struct {
+	mu  sync.Mutex
+	run func(req *cycleRequest, now time.Time)
+	// Whether a cycle is running.
+	running bool
+	// A request waiting for the running cycle.
+	pending *cycleRequest
+	lastID  int
+}
+
+func newCycleRunner(run func(req *cycleRequest, now time.Time)) *cycleRunner {
+	return &cycleRunner{run: run}
+}
+
+// Request a cycle of hosts, or of all hosts if hosts is nil.
+// Forced cycles check hosts regardless of their schedules.
+// Returns the id of the cycle.
+func (c *cycleRunner) request(hosts []string, force bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if req := c.pending; req != nil {
+		req.force = req.force || force
+		if hosts == nil {
+			req.hosts = nil
+		} else if req.hosts != nil {
+			for _, host := range hosts {
+				req.hosts[host] = true
+			}
+		}
+		return req.id
+	}
+
+	c.lastID++
+	req := &cycleRequest{id: c.lastID, force: force}
+	if hosts != nil {
+		req.hosts = make(map[string]bool, len(hosts))
+		for _, host := range hosts {
+			req.hosts[host] = true
+		}
+	}
+	if c.running {
+		c.pending = req
+		return req.id
+	}
+	c.running = true
+	go c.loop(req)
+	return req.id
+}
+
+// Run a cycle, then waiting ones until none are left.
+func (c *cycleRunner) loop(req *cycleRequest) {
+	for req != nil {
+		c.run(req, time.Now())
+		c.mu.Lock()
+		req, c.pending = c.pending, nil
+		c.running = req != nil
+		c.mu.Unlock()
+	}
+}
+
+// A request body of POST /check.
+type checkRequest struct {
+	// Hosts to check. All hosts are checked if it's empty.
+	Hosts []string `json:"hosts"`
+}
+
+// A response of POST /check.
+type checkResponse struct {
+	CycleID int `json:"cycle_id"`
+}
+
+// Request a check cycle like POST /check with an optional JSON body like
+// {"hosts": ["www.example.com"]}.
+// Returns 202 with the cycle id, which GET /status shows once it finishes.
+func checkHandler(config *config, runner *cycleRunner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req checkRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, fmt.Sprintf("malformed JSON: %v", err),
+				http.StatusBadRequest)
+			return
+		}
+		for _, host := range req.Hosts {
+			if err := validateHost(host); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		var hosts []string
+		if len(req.Hosts) > 0 {
+			hosts = req.Hosts
+		}
+
+		id := runner.request(hosts, true)
+		log.Printf("Check cycle %v requested via API", id)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(checkResponse{id}); err != nil {
+			log.Printf("ERROR writing check: %v", err)
+		}
+	}
+}
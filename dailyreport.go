@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go"
+)
+
+// The subject of inventory reports.
+const inventoryReportSubject = "SSL certificate inventory report"
+
+// Schedule of inventory reports mailed to REPORT_EMAILS regardless of
+// reminders.
+type inventoryReport struct {
+	emails []string
+	// Hour and minute of a day to send reports at, in local time.
+	hour, minute int
+	// Weekdays to send reports on, or nil for every day.
+	days map[time.Weekday]bool
+}
+
+// Read REPORT_EMAILS, REPORT_TIME and REPORT_DAYS.
+// Nil if REPORT_EMAILS is not set. Exit process if they're malformed.
+func readInventoryReport() *inventoryReport {
+	emails := splitOptional(envOptional("REPORT_EMAILS", ""))
+	if len(emails) == 0 {
+		return nil
+	}
+	at, err := time.Parse("15:04", envOptional("REPORT_TIME", "09:00"))
+	if err != nil {
+		log.Fatalf("Invalid REPORT_TIME, expected HH:MM: %v", err)
+	}
+	var days map[time.Weekday]bool
+	if value := envOptional("REPORT_DAYS", ""); value != "" {
+		if days, err = parseWeekdays(value); err != nil {
+			log.Fatalf("Invalid REPORT_DAYS: %v", err)
+		}
+	}
+	return &inventoryReport{emails, at.Hour(), at.Minute(), days}
+}
+
+// The next time to send a report after now.
+func (ir *inventoryReport) next(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), ir.hour, ir.minute, 0, 0, now.Location())
+	for !next.After(now) || ir.days != nil && !ir.days[next.Weekday()] {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Send reports on schedule until stop is closed.
+func (ir *inventoryReport) run(config *config, sgConfig *sendgridConfig,
+	status *cycleStatus, stop <-chan struct{}) {
+	for {
+		next := ir.next(time.Now())
+		log.Printf("Next inventory report at %v", next)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case now := <-timer.C:
+			if err := ir.send(config, sgConfig, status.hostReports(config, now), now); err != nil {
+				log.Printf("ERROR sending inventory report: %v", err)
+			}
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// A body of a report listing all hosts in the last cycle, after TOP_N
+// hosts closest to expiry if it's positive and hosts below
+// RELIABILITY_FLOOR if it's set.
+func inventoryReportText(config *config, reports []hostReport, now time.Time) string {
+	var buf bytes.Buffer
+	if top := topExpiring(reports, config.topN); config.topN > 0 && len(top) > 0 {
+		fmt.Fprintf(&buf, "Next %v to expire:\n", len(top))
+		buf.WriteString(topExpiringText(top) + "\n")
+	}
+	if config.history != nil {
+		buf.WriteString(unreliableText(reports, config.reliabilityFloor, config.history.window))
+	}
+	fmt.Fprintf(&buf, "Certificates of %v hosts as of %v:\n\n", len(reports), now.Format(time.RFC1123))
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tSTATUS\tDAYS\tEXPIRATION\tISSUER")
+	for _, r := range reports {
+		if r.Error != "" {
+			fmt.Fprintf(w, "%v\terror\t-\t-\t%v\n", r.Host, r.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", r.Host, r.Tier,
+			math.Floor(*r.DaysRemaining), r.NotAfter.Format("2006-01-02"), r.Issuer)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// Mail a report of hosts to REPORT_EMAILS.
+func (ir *inventoryReport) send(config *config, sgConfig *sendgridConfig,
+	reports []hostReport, now time.Time) error {
+	sg := sendgrid.NewSendGridClient(sgConfig.username, sgConfig.password)
+	msg := sendgrid.NewMail()
+	msg.AddTos(ir.emails)
+	msg.SetSubject(inventoryReportSubject)
+	msg.SetText(inventoryReportText(config, reports, now))
+	msg.SetFrom(config.from)
+	if err := sg.Send(msg); err != nil {
+		return fmt.Errorf("sending mail to %v: %v", ir.emails, err)
+	}
+	log.Printf("Inventory report of %v hosts sent to %v", len(reports), ir.emails)
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed assets
+var assets embed.FS
+
+var dashboardTemplate = template.Must(
+	template.ParseFS(assets, "assets/dashboard.html"))
+
+var hostTemplate = template.Must(template.ParseFS(assets, "assets/host.html"))
+
+// A row of the dashboard.
+type dashboardRow struct {
+	Host       string
+	Source     string
+	Tier       string
+	Days       string
+	NotAfter   string
+	Issuer     string
+	CheckedAt  string
+	Error      string
+	ErrorClass string
+	days       float64
+}
+
+// Data of the dashboard template.
+type dashboardData struct {
+	Query    string
+	Finished string
+	Next     string
+	Rows     []dashboardRow
+}
+
+// Format a time in TIMEZONE for the dashboard.
+func (config *config) formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.In(config.location).Format("2006-01-02 15:04 MST")
+}
+
+// Data of the host template.
+type hostPage struct {
+	dashboardRow
+	Tags        map[string]string
+	ChainDepth  int
+	TLSVersion  string
+	CipherSuite string
+	Findings    map[string]string
+	// The threshold applied and why.
+	ThresholdDays   int
+	ThresholdSource string
+}
+
+// Build a row of the dashboard from a report.
+func newDashboardRow(config *config, report hostReport) dashboardRow {
+	row := dashboardRow{
+		Host:       report.Host,
+		Source:     report.Source,
+		Tier:       report.Tier,
+		Issuer:     report.Issuer,
+		CheckedAt:  config.formatTime(report.CheckedAt),
+		Error:      report.Error,
+		ErrorClass: report.ErrorClass,
+	}
+	if report.Error != "" {
+		row.Tier = "error"
+	} else {
+		row.days = *report.DaysRemaining
+		row.Days = fmt.Sprintf("%.0f", row.days)
+		row.NotAfter = config.formatTime(*report.NotAfter)
+	}
+	return row
+}
+
+// Rows of hosts matching the query, errored first and then
+// sorted by days remaining.
+func dashboardRows(config *config, reports []hostReport, query string) []dashboardRow {
+	var rows []dashboardRow
+	for _, report := range reports {
+		if query != "" && !strings.Contains(report.Host, query) {
+			continue
+		}
+		rows = append(rows, newDashboardRow(config, report))
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if (rows[i].Error != "") != (rows[j].Error != "") {
+			return rows[i].Error != ""
+		}
+		return rows[i].days < rows[j].days
+	})
+	return rows
+}
+
+// Serve the dashboard of the latest results in memory.
+func dashboardHandler(config *config, status *cycleStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		now := time.Now()
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		resp := status.statusResponse(config, now)
+		data := dashboardData{
+			Query:    query,
+			Finished: config.formatTime(resp.Finished),
+			Next:     config.formatTime(resp.Next),
+			Rows:     dashboardRows(config, resp.Hosts, query),
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardTemplate.Execute(w, data); err != nil {
+			log.Printf("ERROR rendering dashboard: %v", err)
+		}
+	}
+}
+
+// URL of the details page of a host, or "" unless DETAIL_LINKS is enabled.
+func (config *config) detailURL(host string) string {
+	if !config.detailLinks {
+		return ""
+	}
+	return strings.TrimSuffix(config.baseURL, "/") + "/host/" + url.PathEscape(host)
+}
+
+// Serve the details page of a host like GET /host/www.example.com.
+func hostHandler(config *config, status *cycleStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := status.lastCheck(strings.TrimPrefix(r.URL.Path, "/host/"))
+		if c == nil {
+			http.NotFound(w, r)
+			return
+		}
+		report := newHostReport(config, c, time.Now())
+		page := hostPage{
+			dashboardRow:    newDashboardRow(config, report),
+			Tags:            report.Tags,
+			ChainDepth:      report.ChainDepth,
+			TLSVersion:      report.TLSVersion,
+			CipherSuite:     report.CipherSuite,
+			Findings:        report.Findings,
+			ThresholdDays:   report.ThresholdDays,
+			ThresholdSource: report.ThresholdSource,
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := hostTemplate.Execute(w, page); err != nil {
+			log.Printf("ERROR rendering host: %v", err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// Discovers hosts by a query of a SQL database like a CMDB.
+// Columns of the query are host, and optionally port, threshold and
+// emails by their names.
+type databaseSource struct {
+	db    *sql.DB
+	query string
+
+	mu sync.Mutex
+	// The last targets queried successfully, reused on errors.
+	last []target
+	// Threshold days by hosts of the last targets.
+	thresholds map[string]int
+}
+
+// Read HOSTS_DB_DRIVER, HOSTS_DB_DSN and HOSTS_DB_QUERY.
+// Returns nil if HOSTS_DB_QUERY is not set.
+func readDatabaseSource() *databaseSource {
+	query := envOptional("HOSTS_DB_QUERY", "")
+	if query == "" {
+		return nil
+	}
+	driver := envOptional("HOSTS_DB_DRIVER", "postgres")
+	if driver != "postgres" && driver != "mysql" {
+		log.Fatalf("HOSTS_DB_DRIVER must be postgres or mysql: %q", driver)
+	}
+	db, err := sql.Open(driver, envMandatory("HOSTS_DB_DSN"))
+	if err != nil {
+		log.Fatalf("Failed to open HOSTS_DB_DSN: %v", err)
+	}
+	return &databaseSource{db: db, query: query}
+}
+
+func (d *databaseSource) name() string {
+	return "database"
+}
+
+// Targets of rows of the query, or the last ones if the query fails.
+func (d *databaseSource) discover() ([]target, error) {
+	targets, thresholds, err := d.queryTargets()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		if d.last == nil {
+			return nil, err
+		}
+		log.Printf("ERROR querying hosts, reusing %v hosts of the last query: %v",
+			len(d.last), err)
+		return d.last, nil
+	}
+	d.last, d.thresholds = targets, thresholds
+	return targets, nil
+}
+
+// Run the query within a minute.
+func (d *databaseSource) queryTargets() ([]target, map[string]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rows, err := d.db.QueryContext(ctx, d.query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		index[strings.ToLower(column)] = i
+	}
+	if _, ok := index["host"]; !ok {
+		return nil, nil, fmt.Errorf("No host column in %v", columns)
+	}
+
+	var targets []target
+	thresholds := make(map[string]int)
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, err
+		}
+		column := func(name string) string {
+			if i, ok := index[name]; ok && values[i].Valid {
+				return strings.TrimSpace(values[i].String)
+			}
+			return ""
+		}
+		t := target{host: column("host"), source: "database"}
+		if t.host == "" {
+			continue
+		}
+		if port := column("port"); port != "" {
+			t.host = net.JoinHostPort(t.host, port)
+		}
+		if threshold := column("threshold"); threshold != "" {
+			days, err := strconv.Atoi(threshold)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Invalid threshold %q of %v", threshold, t.host)
+			}
+			thresholds[t.host] = days
+		}
+		if emails := column("emails"); emails != "" {
+			t.tags = map[string]string{"emails": strings.Replace(emails, ",", "|", -1)}
+		}
+		targets = append(targets, t)
+	}
+	return targets, thresholds, rows.Err()
+}
+
+// The threshold days of a host given by the query, if any.
+func (d *databaseSource) threshold(host string) (int, bool) {
+	if d == nil {
+		return 0, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	days, ok := d.thresholds[host]
+	return days, ok
+}
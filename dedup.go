@@ -0,0 +1,86 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// A notification of a host via a notifier, kept in STATE_FILE.
+type notification struct {
+	At   time.Time `json:"at"`
+	Tier tier      `json:"tier"`
+}
+
+// Read dedup windows by notifiers like email=24h,slack=4h.
+// Exit process if it's malformed.
+func readDedupWindows(stateFile string) map[string]time.Duration {
+	specs := splitOptional(envOptional("NOTIFY_DEDUP", ""))
+	if len(specs) == 0 {
+		return nil
+	}
+	if stateFile == "" {
+		log.Fatalf("NOTIFY_DEDUP requires STATE_FILE")
+	}
+	windows := make(map[string]time.Duration)
+	for _, spec := range specs {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 {
+			log.Fatalf("Failed to parse NOTIFY_DEDUP: %q", spec)
+		}
+		window, err := time.ParseDuration(kv[1])
+		if err != nil {
+			log.Fatalf("Failed to parse NOTIFY_DEDUP: %q: %v", spec, err)
+		}
+		windows[kv[0]] = window
+	}
+	return windows
+}
+
+// Whether a reminder is skipped via a notifier, as every host in it was
+// notified via the notifier in the same tier within its dedup window.
+// Reminders without hosts expiring, e.g. only of findings, are never.
+func (config *config) deduplicated(notifier string, rem *reminder) bool {
+	window := config.dedupWindows[notifier]
+	if window <= 0 || rem.prev == nil || len(rem.Soon) == 0 {
+		return false
+	}
+	for _, h := range rem.Soon {
+		n, ok := rem.prev.Hosts[h.Host].Notified[notifier]
+		if !ok || n.Tier.String() != h.Tier || rem.Time.Sub(n.At) >= window {
+			return false
+		}
+	}
+	return true
+}
+
+// Record notifications of hosts in a reminder via notifiers in state,
+// keeping earlier ones of other notifiers while hosts stay in their tiers.
+func (s *state) recordNotifications(rem *reminder, notifiers []string) {
+	if rem.prev != nil {
+		for host, h := range s.Hosts {
+			for name, n := range rem.prev.Hosts[host].Notified {
+				if n.Tier == h.Tier {
+					if h.Notified == nil {
+						h.Notified = make(map[string]notification)
+					}
+					h.Notified[name] = n
+				}
+			}
+			s.Hosts[host] = h
+		}
+	}
+	for _, report := range rem.Soon {
+		h, ok := s.Hosts[report.Host]
+		if !ok {
+			continue
+		}
+		if h.Notified == nil {
+			h.Notified = make(map[string]notification)
+		}
+		for _, name := range notifiers {
+			h.Notified[name] = notification{rem.Time, h.Tier}
+		}
+		s.Hosts[report.Host] = h
+	}
+}
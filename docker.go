@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Matches Traefik rules like Host(`a.example.com`, `b.example.com`)
+// and HostSNI(`a.example.com`).
+var traefikHostRule = regexp.MustCompile("Host(?:SNI)?\\(([^)]*)\\)")
+
+// Matches quoted hosts in a Traefik rule.
+var traefikQuoted = regexp.MustCompile("[`\"']([^`\"']+)[`\"']")
+
+// Discovers hosts from labels of running Docker containers.
+type dockerSource struct {
+	client  *http.Client
+	baseURL string
+	label   string
+	traefik bool
+
+	mu       sync.Mutex
+	previous map[string]string
+}
+
+// A container in the output of the Docker Engine API.
+type dockerContainer struct {
+	Names  []string
+	Labels map[string]string
+}
+
+// Read Docker discovery configs.
+// Returns nil if DOCKER_DISCOVERY is not enabled.
+// Exit process if the Docker daemon isn't accessible.
+func readDockerSource() *dockerSource {
+	if !envOptionalBool("DOCKER_DISCOVERY", false) {
+		return nil
+	}
+	dockerHost := envOptional("DOCKER_HOST", "unix:///var/run/docker.sock")
+	client, baseURL, err := dockerClient(dockerHost)
+	if err != nil {
+		log.Fatalf("Failed to parse DOCKER_HOST: %v", err)
+	}
+	d := &dockerSource{
+		client:  client,
+		baseURL: baseURL,
+		label:   envOptional("DOCKER_LABEL", "sslreminder.hosts"),
+		traefik: envOptionalBool("DOCKER_TRAEFIK", false),
+	}
+	if err := d.ping(); err != nil {
+		log.Fatalf("Failed to access Docker at %v: %v", dockerHost, err)
+	}
+	return d
+}
+
+// Get an HTTP client and base URL talking to the Docker daemon at DOCKER_HOST.
+func dockerClient(dockerHost string) (*http.Client, string, error) {
+	u, err := url.Parse(dockerHost)
+	if err != nil {
+		return nil, "", err
+	}
+	switch u.Scheme {
+	case "unix":
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", u.Path)
+			},
+		}
+		client := &http.Client{Transport: transport, Timeout: 30 * time.Second}
+		return client, "http://docker", nil
+	case "tcp", "http":
+		return &http.Client{Timeout: 30 * time.Second}, "http://" + u.Host, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+}
+
+// Make a GET request to the Docker daemon and decode JSON response into v.
+func (d *dockerSource) get(path string, v interface{}) error {
+	resp, err := d.client.Get(d.baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %v for %v", resp.Status, path)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Ensure the daemon is accessible, e.g. the socket is permitted.
+func (d *dockerSource) ping() error {
+	return d.get("/_ping", nil)
+}
+
+func (d *dockerSource) name() string {
+	return "Docker"
+}
+
+// Hosts of a container from its labels.
+func (d *dockerSource) containerHosts(c dockerContainer) []string {
+	var hosts []string
+	if value, ok := c.Labels[d.label]; ok {
+		for _, host := range strings.Split(value, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	if d.traefik {
+		for key, value := range c.Labels {
+			if !strings.HasPrefix(key, "traefik.") ||
+				!strings.HasSuffix(key, ".rule") {
+				continue
+			}
+			hosts = append(hosts, traefikHosts(value)...)
+		}
+	}
+	return hosts
+}
+
+// Extract hosts from a Traefik rule, skipping wildcards.
+func traefikHosts(rule string) []string {
+	var hosts []string
+	for _, m := range traefikHostRule.FindAllStringSubmatch(rule, -1) {
+		for _, q := range traefikQuoted.FindAllStringSubmatch(m[1], -1) {
+			if !strings.Contains(q[1], "*") {
+				hosts = append(hosts, q[1])
+			}
+		}
+	}
+	return hosts
+}
+
+func (d *dockerSource) discover() ([]target, error) {
+	var containers []dockerContainer
+	if err := d.get("/containers/json", &containers); err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]string)
+	var targets []target
+	for _, c := range containers {
+		containerName := ""
+		if len(c.Names) > 0 {
+			containerName = strings.TrimPrefix(c.Names[0], "/")
+		}
+		for _, host := range d.containerHosts(c) {
+			if _, ok := current[host]; ok {
+				continue
+			}
+			current[host] = containerName
+			targets = append(targets, target{
+				host:   host,
+				source: fmt.Sprintf("Docker container %v", containerName),
+			})
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for host, containerName := range d.previous {
+		if _, ok := current[host]; !ok {
+			log.Printf("%v is no longer discovered, container %v has gone",
+				host, containerName)
+		}
+	}
+	d.previous = current
+	return targets, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Versions selectable by DOWNGRADE_VERSIONS.
+var downgradeVersionNames = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Outcomes of handshakes forced to a version.
+const (
+	downgradeAccepted = "accepted"
+	downgradeRejected = "rejected"
+)
+
+// Read DOWNGRADE_VERSIONS like 1.0,1.1. Nil if it's not set.
+// Exit process if a version is unknown.
+func readDowngradeVersions() []uint16 {
+	var versions []uint16
+	for _, name := range splitOptional(envOptional("DOWNGRADE_VERSIONS", "")) {
+		version, ok := downgradeVersionNames[name]
+		if !ok {
+			log.Fatalf("Unknown version %q in DOWNGRADE_VERSIONS, expected 1.0, 1.1, 1.2 or 1.3", name)
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// Handshake with a target forced to each version, by version names like
+// "TLS 1.0". Outcomes are accepted, rejected, or "failed: ..." if the
+// connection itself failed. Certificates are not verified, which is done
+// by the default handshake.
+func downgradeTest(t target, versions []uint16, timeout time.Duration) map[string]string {
+	outcomes := make(map[string]string)
+	for _, version := range versions {
+		cfg := tlsConfig(t)
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = nil
+		cfg.MinVersion = version
+		cfg.MaxVersion = version
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(hostPort(t.origin()))
+		}
+		outcomes[tls.VersionName(version)] = forcedHandshake(t, cfg, timeout)
+	}
+	return outcomes
+}
+
+// The outcome of a handshake with a target by cfg.
+func forcedHandshake(t target, cfg *tls.Config, timeout time.Duration) string {
+	raw, err := net.DialTimeout("tcp", t.dialAddr(), timeout)
+	if err != nil {
+		return fmt.Sprintf("failed: %v", err)
+	}
+	defer raw.Close()
+	raw.SetDeadline(time.Now().Add(timeout))
+	if err := startTLS(raw, t.protocol()); err != nil {
+		return fmt.Sprintf("failed: %v", err)
+	}
+	if err := tls.Client(raw, cfg).Handshake(); err != nil {
+		return downgradeRejected
+	}
+	return downgradeAccepted
+}
+
+// Deprecated versions below TLS 1.2 accepted by forced handshakes,
+// like "TLS 1.0, TLS 1.1", or "" if none are.
+func acceptedDeprecated(outcomes map[string]string) string {
+	var accepted []string
+	for _, version := range downgradeVersionNames {
+		name := tls.VersionName(version)
+		if version < tls.VersionTLS12 && outcomes[name] == downgradeAccepted {
+			accepted = append(accepted, name)
+		}
+	}
+	sort.Strings(accepted)
+	return strings.Join(accepted, ", ")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// Classes of check errors.
+const (
+	errorDNS         = "dns"
+	errorTimeout     = "timeout"
+	errorRefused     = "refused"
+	errorReset       = "reset"
+	errorCertificate = "certificate"
+	errorTLS         = "tls"
+	errorFile        = "file"
+	errorOther       = "other"
+)
+
+// Classify an error of checking a host.
+func errorClass(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var verifyErr *tls.CertificateVerificationError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var alertErr tls.AlertError
+	var recordErr tls.RecordHeaderError
+	var pathErr *os.PathError
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return errorDNS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return errorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return errorRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return errorReset
+	case errors.As(err, &verifyErr), errors.As(err, &unknownAuthErr),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return errorCertificate
+	case errors.As(err, &alertErr), errors.As(err, &recordErr):
+		return errorTLS
+	case errors.As(err, &pathErr):
+		return errorFile
+	default:
+		return errorOther
+	}
+}
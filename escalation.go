@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Whether reminders of a host are escalated to ESCALATION_EMAILS,
+// as it needs attention and remains unacknowledged after
+// ESCALATE_AFTER reminders including this one.
+func (config *config) escalated(prev *state, host string, r *result, now time.Time) bool {
+	if config.escalateAfter <= 0 || prev == nil ||
+		config.tierOf(host, r, now) == tierOK {
+		return false
+	}
+	before, ok := prev.Hosts[host]
+	if !ok || before.Acked && before.NotAfter.Equal(r.notAfter) {
+		return false
+	}
+	return before.Reminded+1 >= config.escalateAfter
+}
+
+// Acknowledge a host like "sslreminder ack www.example.com" in STATE_FILE,
+// which stops escalating it until its certificate changes.
+// Exit process on errors.
+func ackCommand(args []string) {
+	if len(args) != 1 {
+		log.Fatalf("Usage: sslreminder ack <host>")
+	}
+	host := args[0]
+	path := envMandatory("STATE_FILE")
+	s, err := loadState(path)
+	if err != nil {
+		log.Fatalf("Failed to load state: %v", err)
+	}
+	if s == nil {
+		log.Fatalf("No state in %v yet", path)
+	}
+	h, ok := s.Hosts[host]
+	if !ok {
+		log.Fatalf("%v was not reminded", host)
+	}
+	h.Acked = true
+	h.Reminded = 0
+	h.UpdatedAt = time.Now()
+	s.Hosts[host] = h
+	if err := saveState(path, s); err != nil {
+		log.Fatalf("Failed to save state: %v", err)
+	}
+	log.Printf("Acknowledged %v", host)
+}
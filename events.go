@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Types of events in GET /events.
+const (
+	eventCycleStarted  = "cycle_started"
+	eventHostChecked   = "host_checked"
+	eventCycleFinished = "cycle_finished"
+	eventNotified      = "notified"
+)
+
+const (
+	// The number of recent events kept for Last-Event-ID.
+	eventBufferSize = 256
+	// Events queued per client before it's dropped as too slow.
+	eventQueueSize = 64
+	// Time to write an event before the client is dropped.
+	eventWriteTimeout = 10 * time.Second
+	// Interval of comments keeping idle streams alive.
+	eventKeepAlive = 30 * time.Second
+)
+
+// An event of check cycles.
+type event struct {
+	ID      int64       `json:"id"`
+	Type    string      `json:"type"`
+	Time    time.Time   `json:"time"`
+	CycleID int         `json:"cycle_id,omitempty"`
+	Host    *hostReport `json:"host,omitempty"`
+	// Of notified events.
+	Notifier string `json:"notifier,omitempty"`
+	OK       *bool  `json:"ok,omitempty"`
+	// Of cycle_finished events.
+	Failed *bool `json:"failed,omitempty"`
+}
+
+// Broadcasts events to clients without blocking publishers.
+type eventHub struct {
+	mu     sync.Mutex
+	lastID int64
+	// Recent events, oldest first.
+	recent  []event
+	clients map[chan event]bool
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{clients: make(map[chan event]bool)}
+}
+
+// Publish an event. Clients whose queues are full are dropped.
+func (h *eventHub) publish(e event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	e.ID = h.lastID
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	h.recent = append(h.recent, e)
+	if len(h.recent) > eventBufferSize {
+		h.recent = h.recent[len(h.recent)-eventBufferSize:]
+	}
+	for ch := range h.clients {
+		select {
+		case ch <- e:
+		default:
+			delete(h.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe to events after an id.
+// Returns buffered events after it and a channel of further events,
+// which is closed when the client is dropped.
+func (h *eventHub) subscribe(after int64) ([]event, chan event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var missed []event
+	for _, e := range h.recent {
+		if e.ID > after {
+			missed = append(missed, e)
+		}
+	}
+	ch := make(chan event, eventQueueSize)
+	h.clients[ch] = true
+	return missed, ch
+}
+
+func (h *eventHub) unsubscribe(ch chan event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[ch] {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// Write an event in the SSE format within eventWriteTimeout.
+func writeEvent(w http.ResponseWriter, rc *http.ResponseController, e event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := rc.SetWriteDeadline(time.Now().Add(eventWriteTimeout)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+// Stream events in Server-Sent Events.
+// Events after Last-Event-ID are replayed if they're still buffered.
+func eventsHandler(status *cycleStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var after int64
+		if value := r.Header.Get("Last-Event-ID"); value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+			after = id
+		}
+		missed, ch := status.events.subscribe(after)
+		defer status.events.unsubscribe(ch)
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			log.Printf("ERROR streaming events: %v", err)
+			return
+		}
+		for _, e := range missed {
+			if err := writeEvent(w, rc, e); err != nil {
+				return
+			}
+		}
+
+		keepAlive := time.NewTicker(eventKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case e, ok := <-ch:
+				if !ok {
+					log.Printf("Dropped a slow event stream of %v", r.RemoteAddr)
+					return
+				}
+				if err := writeEvent(w, rc, e); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				rc.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Runs a command on reminders, passing them in JSON on stdin.
+type execNotifier struct {
+	command string
+	timeout time.Duration
+}
+
+func (x *execNotifier) name() string {
+	return "exec"
+}
+
+func (x *execNotifier) recipients() string {
+	return x.command
+}
+
+// Run the command by the shell, failing if it exits with non-zero status
+// or doesn't finish within the timeout.
+// Its stderr is logged either way.
+func (x *execNotifier) notify(rem *reminder) error {
+	body, err := json.Marshal(rem)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), x.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", x.command)
+	cmd.Stdin = bytes.NewReader(body)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		log.Printf("stderr of %v: %v", x.command, msg)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("running %v: timed out after %v", x.command, x.timeout)
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return fmt.Errorf("running %v: exit code %v", x.command, exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("running %v: %v", x.command, err)
+	}
+	log.Printf("Ran %v", x.command)
+	return nil
+}
+
+// Check the syntax of the command without running it, and that its
+// first word is an executable.
+func (x *execNotifier) ping() error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-n", "-c", x.command)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("parsing %v: %v", x.command, strings.TrimSpace(stderr.String()))
+	}
+	if fields := strings.Fields(x.command); len(fields) > 0 {
+		if _, err := exec.LookPath(fields[0]); err != nil {
+			return fmt.Errorf("finding %v: %v", fields[0], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A finding is a problem of a host other than expiration.
+// Hosts having it are reminded in its own section.
+type finding struct {
+	name string
+	// Heading of the section in reminders.
+	heading string
+	// Returns a detail of the problem, or "" if the host doesn't have it.
+	detect func(config *config, t target, r *result, now time.Time) string
+}
+
+var findings = []finding{
+	{
+		"long_chain",
+		"Chains of following hosts are longer than MAX_CHAIN_DEPTH:",
+		func(config *config, t target, r *result, now time.Time) string {
+			if !config.chainTooLong(r) {
+				return ""
+			}
+			return fmt.Sprintf("%v certificates", len(r.chain))
+		},
+	},
+	{
+		"chain_order",
+		"Following hosts serve chains out of order:",
+		func(config *config, t target, r *result, now time.Time) string {
+			if !config.checkChainOrder {
+				return ""
+			}
+			return r.chainOrder
+		},
+	},
+	{
+		"file_mismatch",
+		"Following hosts don't serve their certificate files:",
+		func(config *config, t target, r *result, now time.Time) string {
+			if !r.fileMismatch {
+				return ""
+			}
+			return t.certFile
+		},
+	},
+	{
+		"ip_divergence",
+		"Addresses of following hosts serve different certificates:",
+		func(config *config, t target, r *result, now time.Time) string {
+			return r.ipDivergence
+		},
+	},
+	{
+		"issuer_not_allowed",
+		"Policy violation: certificates of following hosts are issued by CAs not allowed:",
+		func(config *config, t target, r *result, now time.Time) string {
+			if len(r.chain) == 0 || config.issuerAllowed(t, r.chain[0]) {
+				return ""
+			}
+			return r.chain[0].Issuer.String()
+		},
+	},
+	{
+		"wildcard",
+		"Following hosts serve wildcard certificates against NO_WILDCARD:",
+		func(config *config, t target, r *result, now time.Time) string {
+			if len(r.chain) == 0 || !config.noWildcard(t) {
+				return ""
+			}
+			return strings.Join(wildcardNames(r.chain[0]), ", ")
+		},
+	},
+	{
+		"revocation_unreachable",
+		"Revocation endpoints of following hosts are unreachable:",
+		func(config *config, t target, r *result, now time.Time) string {
+			return strings.Join(r.unreachableEndpoints, ", ")
+		},
+	},
+	{
+		"late_renewal",
+		"Following hosts are not renewed yet, though they usually are. Automation may be broken:",
+		func(config *config, t target, r *result, now time.Time) string {
+			return config.lateRenewal(t.host, r, now)
+		},
+	},
+	{
+		"overdue_renewal",
+		"Following hosts are past the renewal points of their CAs' lifetimes:",
+		func(config *config, t target, r *result, now time.Time) string {
+			return config.overdueRenewal(r, now)
+		},
+	},
+	{
+		"tls_profile",
+		"Following hosts can't negotiate within TLS_PROFILE:",
+		func(config *config, t target, r *result, now time.Time) string {
+			return r.profileViolation
+		},
+	},
+	{
+		"deprecated_tls",
+		"Following hosts accept deprecated TLS versions when forced to:",
+		func(config *config, t target, r *result, now time.Time) string {
+			return acceptedDeprecated(r.downgrades)
+		},
+	},
+	{
+		"implausible_expiration",
+		"Following hosts have implausibly far expiration dates:",
+		func(config *config, t target, r *result, now time.Time) string {
+			if config.maxPlausibleYears <= 0 {
+				return ""
+			}
+			if r.notAfter.After(r.notBefore.AddDate(config.maxPlausibleYears, 0, 0)) {
+				return fmt.Sprintf("valid for %.0f days from %v to %v",
+					secondsBetween(r.notBefore, r.notAfter)/(24*60*60), r.notBefore, r.notAfter)
+			}
+			if r.notAfter.After(now.AddDate(config.maxPlausibleYears, 0, 0)) {
+				return fmt.Sprintf("expires at %v", r.notAfter)
+			}
+			return ""
+		},
+	},
+}
+
+// Findings of a host by names.
+func hostFindings(config *config, t target, r *result, now time.Time) map[string]string {
+	var found map[string]string
+	for _, f := range findings {
+		if detail := f.detect(config, t, r, now); detail != "" {
+			if found == nil {
+				found = make(map[string]string)
+			}
+			found[f.name] = detail
+		}
+	}
+	return found
+}
+
+// Whether the issuer of a leaf is in ALLOWED_ISSUERS, or the
+// allowed_issuers tag like "Let's Encrypt|DigiCert" of the target.
+// Any issuer is allowed without them or with the private_ca=true tag.
+func (config *config) issuerAllowed(t target, leaf *x509.Certificate) bool {
+	allowed := config.allowedIssuers
+	if value, ok := t.tags["allowed_issuers"]; ok {
+		allowed = strings.Split(value, "|")
+	}
+	if len(allowed) == 0 || t.tags["private_ca"] == "true" {
+		return true
+	}
+	names := append([]string{leaf.Issuer.CommonName}, leaf.Issuer.Organization...)
+	for _, name := range names {
+		for _, a := range allowed {
+			if a != "" && strings.Contains(strings.ToLower(name), strings.ToLower(a)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Whether wildcard certificates are forbidden for a target by
+// NO_WILDCARD, or the no_wildcard tag overriding it.
+func (config *config) noWildcard(t target) bool {
+	if value, ok := t.tags["no_wildcard"]; ok {
+		return value == "true"
+	}
+	return config.noWildcardDefault
+}
+
+// Wildcard names of a certificate like *.example.com.
+func wildcardNames(cert *x509.Certificate) []string {
+	var names []string
+	for _, name := range append([]string{cert.Subject.CommonName}, cert.DNSNames...) {
+		if strings.HasPrefix(name, "*.") && !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
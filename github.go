@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The marker of issues opened by sslreminder, to update rather than
+// duplicate them.
+const githubIssueMarker = "<!-- sslreminder -->"
+
+// Opens or updates a GitHub issue of hosts needing attention on
+// reminders, and closes it once no hosts do.
+type githubNotifier struct {
+	apiURL string
+	token  string
+	// A repository like owner/name.
+	repo   string
+	labels []string
+}
+
+// Read GITHUB_TOKEN and GITHUB_REPO. Returns nil if GITHUB_REPO is not set.
+func readGitHubNotifier() *githubNotifier {
+	repo := envOptional("GITHUB_REPO", "")
+	if repo == "" {
+		return nil
+	}
+	if strings.Count(repo, "/") != 1 {
+		log.Fatalf("GITHUB_REPO must be like owner/name: %q", repo)
+	}
+	return &githubNotifier{
+		apiURL: strings.TrimSuffix(envOptional("GITHUB_API_URL", "https://api.github.com"), "/"),
+		token:  envMandatory("GITHUB_TOKEN"),
+		repo:   repo,
+		labels: splitOptional(envOptional("GITHUB_LABELS", "ssl-certificate")),
+	}
+}
+
+func (g *githubNotifier) name() string {
+	return "github"
+}
+
+func (g *githubNotifier) recipients() string {
+	return "issues of " + g.repo
+}
+
+// An issue of the GitHub API.
+type githubIssue struct {
+	Number int      `json:"number,omitempty"`
+	Title  string   `json:"title,omitempty"`
+	Body   string   `json:"body,omitempty"`
+	State  string   `json:"state,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Call the GitHub API, decoding the response into out unless it's nil.
+func (g *githubNotifier) call(method, path string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, g.apiURL+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %v of %v %v", resp.Status, method, path)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Check that the repository has issues and the token can manage them.
+func (g *githubNotifier) ping() error {
+	var repo struct {
+		HasIssues   bool `json:"has_issues"`
+		Permissions *struct {
+			Push   bool `json:"push"`
+			Triage bool `json:"triage"`
+		} `json:"permissions"`
+	}
+	if err := g.call(http.MethodGet, "/repos/"+g.repo, nil, &repo); err != nil {
+		return fmt.Errorf("getting %v: %v", g.repo, err)
+	}
+	if !repo.HasIssues {
+		return fmt.Errorf("issues of %v are disabled", g.repo)
+	}
+	if repo.Permissions != nil && !repo.Permissions.Push && !repo.Permissions.Triage {
+		return fmt.Errorf("GITHUB_TOKEN can't close issues of %v", g.repo)
+	}
+	return nil
+}
+
+// The open issue having the marker among the latest ones, or nil.
+// Issues may be relabeled by people, so they aren't filtered by labels.
+func (g *githubNotifier) openIssue() (*githubIssue, error) {
+	var issues []githubIssue
+	path := fmt.Sprintf("/repos/%v/issues?state=open&per_page=100", g.repo)
+	if err := g.call(http.MethodGet, path, nil, &issues); err != nil {
+		return nil, err
+	}
+	for i := range issues {
+		if strings.Contains(issues[i].Body, githubIssueMarker) {
+			return &issues[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// The body of an issue, a task list of hosts needing attention followed
+// by the whole reminder.
+func githubIssueBody(rem *reminder) string {
+	var buf bytes.Buffer
+	buf.WriteString(githubIssueMarker + "\n")
+	for _, h := range rem.Soon {
+		line := fmt.Sprintf("- [ ] `%v` %v", h.Host, h.Tier)
+		if h.NotAfter != nil {
+			line += fmt.Sprintf(", expires at %v", h.NotAfter.UTC().Format(time.RFC3339))
+		}
+		buf.WriteString(line + "\n")
+	}
+	buf.WriteString("\n<details><summary>Reminder</summary>\n\n```\n")
+	buf.WriteString(reminderText(rem))
+	buf.WriteString("```\n\n</details>\n")
+	return buf.String()
+}
+
+// Update the open issue with the reminder, or open one.
+func (g *githubNotifier) notify(rem *reminder) error {
+	issue, err := g.openIssue()
+	if err != nil {
+		return fmt.Errorf("finding issue in %v: %v", g.repo, err)
+	}
+	update := githubIssue{Title: rem.Subject, Body: githubIssueBody(rem), Labels: g.labels}
+	if issue == nil {
+		var opened githubIssue
+		if err := g.call(http.MethodPost, fmt.Sprintf("/repos/%v/issues", g.repo),
+			update, &opened); err != nil {
+			return fmt.Errorf("opening issue in %v: %v", g.repo, err)
+		}
+		log.Printf("Opened issue #%v in %v", opened.Number, g.repo)
+		return nil
+	}
+	if err := g.call(http.MethodPatch, fmt.Sprintf("/repos/%v/issues/%v", g.repo, issue.Number),
+		update, nil); err != nil {
+		return fmt.Errorf("updating issue #%v in %v: %v", issue.Number, g.repo, err)
+	}
+	log.Printf("Updated issue #%v in %v", issue.Number, g.repo)
+	return nil
+}
+
+// Close the open issue if any, as no hosts need attention.
+func (g *githubNotifier) resolve() error {
+	issue, err := g.openIssue()
+	if err != nil || issue == nil {
+		return err
+	}
+	if err := g.call(http.MethodPatch, fmt.Sprintf("/repos/%v/issues/%v", g.repo, issue.Number),
+		githubIssue{State: "closed"}, nil); err != nil {
+		return fmt.Errorf("closing issue #%v in %v: %v", issue.Number, g.repo, err)
+	}
+	log.Printf("Closed issue #%v in %v as all certificates are healthy", issue.Number, g.repo)
+	return nil
+}
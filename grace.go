@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+)
+
+// When a host was first seen, remembered in memory and in CYCLE_FILE.
+// prev is the previous cycle, which may be nil.
+// Zero for hosts in prev without first_seen, which were checked before
+// NEW_HOST_GRACE was set and so are no longer new.
+func (s *cycleStatus) firstSeen(host string, prev *cycleSnapshot, now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seen, ok := s.seen[host]; ok {
+		return seen
+	}
+	seen := now
+	if prev != nil {
+		if h, ok := prev.Hosts[host]; ok {
+			seen = time.Time{}
+			if h.FirstSeen != nil {
+				seen = *h.FirstSeen
+			}
+		}
+	}
+	s.seen[host] = seen
+	return seen
+}
+
+// The end of the grace period of a new host by NEW_HOST_GRACE, during which
+// its failures are logged but not alerted. Nil if it's over or disabled.
+func (config *config) graceUntil(t target, now time.Time) *time.Time {
+	if config.newHostGrace <= 0 || t.firstSeen.IsZero() {
+		return nil
+	}
+	until := t.firstSeen.Add(config.newHostGrace)
+	if !now.Before(until) {
+		return nil
+	}
+	return &until
+}
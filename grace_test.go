@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFirstSeen(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	seen := now.AddDate(0, 0, -1)
+	prev := &cycleSnapshot{FinishedAt: now.Add(-time.Hour), Hosts: map[string]hostSnapshot{
+		"seen.example.com": {FirstSeen: &seen},
+		"old.example.com":  {},
+	}}
+	status := newCycleStatus(now)
+	cases := []struct {
+		host string
+		want time.Time
+	}{
+		{"seen.example.com", seen},
+		{"old.example.com", time.Time{}},
+		{"new.example.com", now},
+	}
+	for _, c := range cases {
+		if got := status.firstSeen(c.host, prev, now); !got.Equal(c.want) {
+			t.Errorf("%v: got %v, want %v", c.host, got, c.want)
+		}
+	}
+	// Remembered in memory without CYCLE_FILE.
+	if got := status.firstSeen("new.example.com", nil, now.Add(time.Hour)); !got.Equal(now) {
+		t.Errorf("got %v, want %v", got, now)
+	}
+}
+
+func TestGraceUntil(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	config := &config{newHostGrace: 48 * time.Hour}
+	if until := config.graceUntil(target{firstSeen: now.Add(-time.Hour)}, now); until == nil ||
+		!until.Equal(now.Add(47*time.Hour)) {
+		t.Errorf("new host: got %v, want 47 hours later", until)
+	}
+	if until := config.graceUntil(target{firstSeen: now.Add(-49 * time.Hour)}, now); until != nil {
+		t.Errorf("host seen before grace: got %v, want nil", *until)
+	}
+	if until := config.graceUntil(target{}, now); until != nil {
+		t.Errorf("old host without first seen: got %v, want nil", *until)
+	}
+}
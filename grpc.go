@@ -0,0 +1,195 @@
+//go:build grpc
+
+package main
+
+import (
+	"context"
+	"github.com/tkawachi/sslreminder/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+func init() {
+	startGRPCServer = serveGRPC
+}
+
+// Serves the gRPC API.
+type grpcServer struct {
+	api.UnimplementedSSLReminderServer
+	config *config
+	status *cycleStatus
+	probes *probeGroup
+}
+
+// Convert a report to its protobuf message.
+func hostResult(report hostReport) *api.HostResult {
+	result := &api.HostResult{
+		Host:            report.Host,
+		Source:          report.Source,
+		Tags:            report.Tags,
+		CheckedAt:       timestamppb.New(report.CheckedAt),
+		DurationSeconds: report.DurationSecs,
+		Tier:            report.Tier,
+		Issuer:          report.Issuer,
+		ChainDepth:      int32(report.ChainDepth),
+		TlsVersion:      report.TLSVersion,
+		CipherSuite:     report.CipherSuite,
+		Findings:        report.Findings,
+		Error:           report.Error,
+		ErrorClass:      report.ErrorClass,
+	}
+	if report.NotAfter != nil {
+		result.NotAfter = timestamppb.New(*report.NotAfter)
+		result.DaysRemaining = *report.DaysRemaining
+	}
+	return result
+}
+
+func (s *grpcServer) GetStatus(ctx context.Context,
+	req *api.GetStatusRequest) (*api.GetStatusResponse, error) {
+	if !s.status.ready() {
+		return nil, grpcstatus.Error(codes.Unavailable, "first check not finished")
+	}
+	resp := s.status.statusResponse(s.config, time.Now())
+	hosts := make([]*api.HostResult, len(resp.Hosts))
+	for i, report := range resp.Hosts {
+		hosts[i] = hostResult(report)
+	}
+	return &api.GetStatusResponse{
+		Version:           resp.Version,
+		LastCycleStarted:  timestamppb.New(resp.Started),
+		LastCycleFinished: timestamppb.New(resp.Finished),
+		NextCycle:         timestamppb.New(resp.Next),
+		Stale:             resp.Stale,
+		Hosts:             hosts,
+	}, nil
+}
+
+func (s *grpcServer) CheckHost(ctx context.Context,
+	req *api.CheckHostRequest) (*api.HostResult, error) {
+	if err := validateHost(req.Target); err != nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	timeout := defaultProbeTimeout
+	if req.Timeout != nil {
+		d := req.Timeout.AsDuration()
+		if d <= 0 {
+			return nil, grpcstatus.Error(codes.InvalidArgument, "invalid timeout")
+		}
+		if d < maxProbeTimeout {
+			timeout = d
+		} else {
+			timeout = maxProbeTimeout
+		}
+	}
+
+	t := s.status.monitored(req.Target)
+	if t == nil {
+		if !s.config.probeAllowed(req.Target) {
+			return nil, grpcstatus.Error(codes.PermissionDenied, "target is not allowed")
+		}
+		t = &target{host: req.Target}
+	}
+	c := s.probes.do(*t, timeout)
+	s.status.refresh(c)
+	return hostResult(newHostReport(s.config, c, time.Now())), nil
+}
+
+// Convert a record of HISTORY_FILE to its protobuf message, with days
+// remaining as of the check.
+func historyResult(rec historyRecord) *api.HostResult {
+	result := &api.HostResult{
+		Host:      rec.Host,
+		CheckedAt: timestamppb.New(rec.CheckedAt),
+		Error:     rec.Error,
+	}
+	if rec.NotAfter != nil {
+		result.NotAfter = timestamppb.New(*rec.NotAfter)
+		result.DaysRemaining = rec.NotAfter.Sub(rec.CheckedAt).Hours() / 24
+	}
+	return result
+}
+
+func (s *grpcServer) ListHistory(ctx context.Context,
+	req *api.ListHistoryRequest) (*api.ListHistoryResponse, error) {
+	if s.config.history == nil {
+		return nil, grpcstatus.Error(codes.Unimplemented, "HISTORY_FILE is not set")
+	}
+	if req.Host == "" {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "host is required")
+	}
+	var since, until time.Time
+	if req.Since != nil {
+		since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		until = req.Until.AsTime()
+	}
+	records, err := s.config.history.records(req.Host, since, until)
+	if err != nil {
+		log.Printf("ERROR reading HISTORY_FILE: %v", err)
+		return nil, grpcstatus.Error(codes.Internal, "failed to read history")
+	}
+	resp := &api.ListHistoryResponse{}
+	for _, rec := range records {
+		resp.Results = append(resp.Results, historyResult(rec))
+	}
+	return resp, nil
+}
+
+// Require credentials of the HTTP server if they're configured.
+func (s *grpcServer) authorize(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.config.httpAuthToken == "" && s.config.httpAuthUser == "" {
+		return handler(ctx, req)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	given := md.Get("authorization")
+	if len(given) == 0 {
+		return nil, grpcstatus.Error(codes.Unauthenticated, "unauthorized")
+	}
+	r := &http.Request{Header: http.Header{"Authorization": given[:1]}}
+	if !s.config.authorized(r) {
+		return nil, grpcstatus.Error(codes.PermissionDenied, "forbidden")
+	}
+	return handler(ctx, req)
+}
+
+// Listen on GRPC_ADDR and serve the gRPC API in background,
+// with TLS of the HTTP server if it's configured.
+// Exit process if it can't listen.
+func serveGRPC(config *config, status *cycleStatus) func() {
+	if config.grpcAddr == "" {
+		return func() {}
+	}
+	listener, err := net.Listen("tcp", config.grpcAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %v: %v", config.grpcAddr, err)
+	}
+	s := &grpcServer{config: config, status: status, probes: newProbeGroup()}
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(s.authorize)}
+	if config.httpTLSCert != "" || config.httpTLSKey != "" {
+		creds, err := credentials.NewServerTLSFromFile(config.httpTLSCert, config.httpTLSKey)
+		if err != nil {
+			log.Fatalf("Failed to load HTTP_TLS_CERT and HTTP_TLS_KEY: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	server := grpc.NewServer(opts...)
+	api.RegisterSSLReminderServer(server, s)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatalf("ERROR serving gRPC: %v", err)
+		}
+	}()
+	log.Printf("Serving gRPC on %v", listener.Addr())
+	return server.GracefulStop
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A check of a host in HISTORY_FILE, one JSON per line.
+type historyRecord struct {
+	Host      string     `json:"host"`
+	CheckedAt time.Time  `json:"checked_at"`
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+	// Duration of the TLS handshake, if any.
+	HandshakeSecs float64 `json:"handshake_seconds,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// Interval to compact HISTORY_FILE at.
+const historyCompactInterval = 24 * time.Hour
+
+// History of checks in HISTORY_FILE, with renewals learned from it.
+type history struct {
+	mu   sync.Mutex
+	path string
+	// When HISTORY_FILE was last compacted.
+	compacted time.Time
+	// The latest expiration of each host.
+	notAfters map[string]time.Time
+	// Days remaining when each host was renewed, oldest first.
+	renewals map[string][]float64
+	// Outcomes of checks of each host within window, oldest first.
+	outcomes map[string][]checkOutcome
+	window   time.Duration
+}
+
+// An outcome of a check.
+type checkOutcome struct {
+	checkedAt time.Time
+	ok        bool
+	// Zero if the check didn't handshake.
+	handshake time.Duration
+}
+
+// Read HISTORY_FILE to learn renewals.
+// Returns nil if it's not set. Exit process if it's malformed.
+func readHistory() *history {
+	path := envOptional("HISTORY_FILE", "")
+	if len(path) == 0 {
+		return nil
+	}
+	h := &history{
+		path:      path,
+		notAfters: make(map[string]time.Time),
+		renewals:  make(map[string][]float64),
+		outcomes:  make(map[string][]checkOutcome),
+		window:    envOptionalDuration("RELIABILITY_WINDOW", 30*24*time.Hour),
+	}
+	records, err := loadHistoryRecords(path)
+	if err != nil {
+		log.Fatalf("Failed to read HISTORY_FILE: %v", err)
+	}
+	now := time.Now()
+	for _, rec := range records {
+		h.learn(rec, now)
+	}
+	if err := h.compact(now); err != nil {
+		log.Printf("ERROR compacting HISTORY_FILE: %v", err)
+	}
+	return h
+}
+
+// Read records in a history file.
+// Returns nil without error if the file doesn't exist yet.
+func loadHistoryRecords(path string) ([]historyRecord, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []historyRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec historyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("Failed to parse %v: %v", path, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Write records to a history file atomically, replacing it.
+func saveHistoryRecords(path string, records []historyRecord) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := encoder.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// Keep records checked before cutoff only where expirations of their hosts
+// change, which is enough to learn renewals. Errors and unchanged
+// expirations before cutoff are dropped.
+func compactHistoryRecords(records []historyRecord, cutoff time.Time) []historyRecord {
+	last := make(map[string]time.Time)
+	var compacted []historyRecord
+	for _, rec := range records {
+		old := rec.CheckedAt.Before(cutoff)
+		if rec.NotAfter == nil {
+			if !old {
+				compacted = append(compacted, rec)
+			}
+			continue
+		}
+		before, ok := last[rec.Host]
+		last[rec.Host] = *rec.NotAfter
+		if old && ok && before.Equal(*rec.NotAfter) {
+			continue
+		}
+		compacted = append(compacted, rec)
+	}
+	return compacted
+}
+
+// Compact records older than the window in HISTORY_FILE, so that it
+// doesn't grow forever. Done at most once a historyCompactInterval.
+func (h *history) compact(now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if now.Sub(h.compacted) < historyCompactInterval {
+		return nil
+	}
+	h.compacted = now
+	records, err := loadHistoryRecords(h.path)
+	if err != nil {
+		return err
+	}
+	compacted := compactHistoryRecords(records, now.Add(-h.window))
+	if len(compacted) == len(records) {
+		return nil
+	}
+	if err := saveHistoryRecords(h.path, compacted); err != nil {
+		return err
+	}
+	log.Printf("Compacted HISTORY_FILE from %v to %v records", len(records), len(compacted))
+	return nil
+}
+
+// Learn an outcome of a record, and a renewal if it has a later
+// expiration than before.
+func (h *history) learn(rec historyRecord, now time.Time) {
+	if now.Sub(rec.CheckedAt) <= h.window {
+		h.outcomes[rec.Host] = append(h.outcomes[rec.Host],
+			checkOutcome{rec.CheckedAt, rec.Error == "",
+				time.Duration(rec.HandshakeSecs * float64(time.Second))})
+	}
+	if rec.NotAfter == nil {
+		return
+	}
+	if before, ok := h.notAfters[rec.Host]; ok && rec.NotAfter.After(before) {
+		h.renewals[rec.Host] = append(h.renewals[rec.Host],
+			before.Sub(rec.CheckedAt).Hours()/24)
+	}
+	h.notAfters[rec.Host] = *rec.NotAfter
+}
+
+// Append checks to HISTORY_FILE and learn renewals of them.
+func (h *history) record(checks []*hostCheck) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	records := make([]historyRecord, 0, len(checks))
+	for _, c := range checks {
+		rec := historyRecord{Host: c.target.host, CheckedAt: c.checkedAt}
+		if c.err != nil {
+			rec.Error = c.err.Error()
+		} else {
+			rec.NotAfter = &c.result.notAfter
+			rec.HandshakeSecs = c.result.handshake.Seconds()
+		}
+		if err := encoder.Encode(rec); err != nil {
+			return err
+		}
+		records = append(records, rec)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, rec := range records {
+		h.learn(rec, now)
+	}
+	h.prune(now)
+	return nil
+}
+
+func (h *history) name() string {
+	return "history"
+}
+
+// Publish checks of a cycle to HISTORY_FILE, compacting it once a day.
+// Checks are written at once so that a failure doesn't leave a partial cycle.
+func (h *history) publish(res *cycleResult) error {
+	if err := h.record(res.checks); err != nil {
+		return err
+	}
+	return h.compact(time.Now())
+}
+
+// Records of a host checked within since and until, oldest first.
+// Zero since or until is unbounded.
+func (h *history) records(host string, since, until time.Time) ([]historyRecord, error) {
+	h.mu.Lock()
+	records, err := loadHistoryRecords(h.path)
+	h.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var matched []historyRecord
+	for _, rec := range records {
+		if rec.Host != host ||
+			!since.IsZero() && rec.CheckedAt.Before(since) ||
+			!until.IsZero() && rec.CheckedAt.After(until) {
+			continue
+		}
+		matched = append(matched, rec)
+	}
+	return matched, nil
+}
+
+// Typical days remaining when a host is renewed, the median of renewals.
+// Nil for hosts renewed less than twice.
+func (h *history) typicalRenewal(host string) *float64 {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	renewals := h.renewals[host]
+	if len(renewals) < 2 {
+		return nil
+	}
+	m := median(renewals)
+	return &m
+}
+
+// Detail of a certificate not renewed yet, although the host is usually
+// renewed RENEWAL_MARGIN_DAYS before now. Empty if it's not late.
+func (config *config) lateRenewal(host string, r *result, now time.Time) string {
+	typical := config.history.typicalRenewal(host)
+	if typical == nil {
+		return ""
+	}
+	remaining := secondsBetween(now, r.notAfter) / (24 * 60 * 60)
+	if remaining < 0 || remaining >= *typical-float64(config.renewalMarginDays) {
+		return ""
+	}
+	return fmt.Sprintf("%.0f days remaining, usually renewed with %.0f days",
+		remaining, *typical)
+}
+
+// Forget outcomes older than the window.
+func (h *history) prune(now time.Time) {
+	for host, outcomes := range h.outcomes {
+		i := 0
+		for i < len(outcomes) && now.Sub(outcomes[i].checkedAt) > h.window {
+			i++
+		}
+		if i == len(outcomes) {
+			delete(h.outcomes, host)
+		} else {
+			h.outcomes[host] = outcomes[i:]
+		}
+	}
+}
+
+// The ratio of successful checks of a host within RELIABILITY_WINDOW.
+// Nil if it's not checked within it.
+func (h *history) successRate(host string, now time.Time) *float64 {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	total, ok := 0, 0
+	for _, o := range h.outcomes[host] {
+		if now.Sub(o.checkedAt) <= h.window {
+			total++
+			if o.ok {
+				ok++
+			}
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	rate := float64(ok) / float64(total)
+	return &rate
+}
+
+// Write success rates of hosts in the Prometheus text format.
+func (h *history) writeMetrics(w io.Writer, now time.Time) {
+	h.mu.Lock()
+	hosts := make([]string, 0, len(h.outcomes))
+	for host := range h.outcomes {
+		hosts = append(hosts, host)
+	}
+	h.mu.Unlock()
+	sort.Strings(hosts)
+
+	fmt.Fprintln(w, "# HELP ssl_check_success_ratio Ratio of successful checks within RELIABILITY_WINDOW.")
+	fmt.Fprintln(w, "# TYPE ssl_check_success_ratio gauge")
+	for _, host := range hosts {
+		if rate := h.successRate(host, now); rate != nil {
+			fmt.Fprintf(w, "ssl_check_success_ratio{host=%q} %.4f\n", host, *rate)
+		}
+	}
+}
+
+// Hosts whose checks succeeded less than RELIABILITY_FLOOR percent within
+// RELIABILITY_WINDOW, for the summary in inventory reports.
+func unreliableText(reports []hostReport, floor float64, window time.Duration) string {
+	if floor <= 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	for _, r := range reports {
+		if r.SuccessRate != nil && *r.SuccessRate*100 < floor {
+			fmt.Fprintf(&buf, "%v: %.1f%% of checks succeeded in %v\n",
+				r.Host, *r.SuccessRate*100, window)
+		}
+	}
+	if buf.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Checks of following hosts succeeded less than %v%%:\n", floor) +
+		buf.String() + "\n"
+}
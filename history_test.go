@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestHistory(path string) *history {
+	return &history{
+		path:      path,
+		notAfters: make(map[string]time.Time),
+		renewals:  make(map[string][]float64),
+		outcomes:  make(map[string][]checkOutcome),
+		window:    30 * 24 * time.Hour,
+	}
+}
+
+// Daily records of a host for days until now, renewed 30 days before
+// expiration to 90 days of validity.
+func dailyRecords(host string, days int, now time.Time) []historyRecord {
+	var records []historyRecord
+	notAfter := now.AddDate(0, 0, -days+60)
+	for d := days; d > 0; d-- {
+		checkedAt := now.AddDate(0, 0, -d)
+		if notAfter.Sub(checkedAt) <= 30*24*time.Hour {
+			notAfter = notAfter.AddDate(0, 0, 90)
+		}
+		n := notAfter
+		records = append(records, historyRecord{Host: host, CheckedAt: checkedAt, NotAfter: &n})
+	}
+	return records
+}
+
+func TestSuccessRate(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	h := newTestHistory("")
+	for d := 60; d > 0; d-- {
+		rec := historyRecord{Host: "flaky.example.com", CheckedAt: now.AddDate(0, 0, -d)}
+		// Every fifth check fails, and all checks failed out of the window.
+		if d%5 == 0 || d > 30 {
+			rec.Error = "timeout"
+		}
+		h.learn(rec, now)
+	}
+	rate := h.successRate("flaky.example.com", now)
+	if rate == nil || *rate != 0.8 {
+		t.Errorf("got %v, want 0.8", rate)
+	}
+	if rate := h.successRate("unknown.example.com", now); rate != nil {
+		t.Errorf("unknown host: got %v, want nil", *rate)
+	}
+	if rate := h.successRate("flaky.example.com", now.AddDate(0, 0, 31)); rate != nil {
+		t.Errorf("out of window: got %v, want nil", *rate)
+	}
+	var nilHistory *history
+	if rate := nilHistory.successRate("flaky.example.com", now); rate != nil {
+		t.Errorf("without HISTORY_FILE: got %v, want nil", *rate)
+	}
+}
+
+func TestTypicalRenewal(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	h := newTestHistory("")
+	for _, rec := range dailyRecords("www.example.com", 365, now) {
+		h.learn(rec, now)
+	}
+	typical := h.typicalRenewal("www.example.com")
+	if typical == nil || *typical != 30 {
+		t.Errorf("got %v, want 30", typical)
+	}
+
+	h = newTestHistory("")
+	for _, rec := range dailyRecords("new.example.com", 60, now) {
+		h.learn(rec, now)
+	}
+	if typical := h.typicalRenewal("new.example.com"); typical != nil {
+		t.Errorf("renewed less than twice: got %v, want nil", *typical)
+	}
+}
+
+func TestUnreliableText(t *testing.T) {
+	low, high := 0.8, 0.95
+	reports := []hostReport{
+		{Host: "flaky.example.com", SuccessRate: &low},
+		{Host: "www.example.com", SuccessRate: &high},
+		{Host: "new.example.com"},
+	}
+	text := unreliableText(reports, 90, 30*24*time.Hour)
+	if !strings.Contains(text, "flaky.example.com: 80.0%") ||
+		strings.Contains(text, "www.example.com") || strings.Contains(text, "new.example.com") {
+		t.Errorf("got %q", text)
+	}
+	if text := unreliableText(reports, 0, 30*24*time.Hour); text != "" {
+		t.Errorf("without RELIABILITY_FLOOR: got %q", text)
+	}
+}
+
+func TestCompactHistory(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	records := dailyRecords("www.example.com", 365, now)
+	for d := 365; d > 0; d-- {
+		records = append(records, historyRecord{Host: "down.example.com",
+			CheckedAt: now.AddDate(0, 0, -d), Error: "refused"})
+	}
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := saveHistoryRecords(path, records); err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHistory(path)
+	if err := h.compact(now); err != nil {
+		t.Fatal(err)
+	}
+	compacted, err := loadHistoryRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Records within 30 days of both hosts, and ones changing expirations
+	// before them.
+	if len(compacted) >= 100 {
+		t.Errorf("got %v records, want less than 100", len(compacted))
+	}
+
+	before, after := newTestHistory(""), newTestHistory("")
+	for _, rec := range records {
+		before.learn(rec, now)
+	}
+	for _, rec := range compacted {
+		after.learn(rec, now)
+	}
+	for _, host := range []string{"www.example.com", "down.example.com"} {
+		if b, a := before.successRate(host, now), after.successRate(host, now); *b != *a {
+			t.Errorf("success rate of %v: got %v, want %v", host, *a, *b)
+		}
+	}
+	if b, a := before.typicalRenewal("www.example.com"), after.typicalRenewal("www.example.com"); a == nil || *b != *a {
+		t.Errorf("typical renewal: got %v, want %v", a, *b)
+	}
+
+	// Not compacted again within a day.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.compact(now.Add(time.Hour)); err != nil {
+		t.Errorf("compacted again within a day: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// A request body of PUT /hosts.
+type hostsRequest struct {
+	// Hosts in the HOSTS format like www.example.com:8443;team=web.
+	Hosts []string `json:"hosts"`
+}
+
+// Validate a host name with an optional port.
+// Internationalized names like 例え.jp are validated in their ASCII form.
+func validateHost(host string) error {
+	name := host
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("Invalid port of %q", host)
+		}
+		name = h
+	}
+	name, err := asciiHost(name)
+	if err != nil {
+		return fmt.Errorf("Invalid internationalized host %q: %v", host, err)
+	}
+	if len(name) == 0 || len(name) > 253 {
+		return fmt.Errorf("Invalid length of host %q", host)
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			c >= '0' && c <= '9' || c == '-' || c == '.' || c == ':') {
+			return fmt.Errorf("Invalid character %q in host %q", c, host)
+		}
+	}
+	return nil
+}
+
+// Parse hosts in a request body of PUT /hosts.
+func parseHostsRequest(req *hostsRequest) ([]target, error) {
+	if len(req.Hosts) == 0 {
+		return nil, fmt.Errorf("No hosts given")
+	}
+	targets := make([]target, 0, len(req.Hosts))
+	for _, spec := range req.Hosts {
+		t, err := parseTarget(spec)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateHost(t.host); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// Whether a request has the bearer token in "Authorization: Bearer <token>".
+func hasBearerToken(r *http.Request, token string) bool {
+	given := r.Header.Get("Authorization")
+	if !strings.HasPrefix(given, "Bearer ") {
+		return false
+	}
+	given = strings.TrimPrefix(given, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// Replace hosts given by HOSTS with a JSON body like
+// {"hosts": ["www.example.com", "api.example.com:8443;team=api"]}.
+func hostsHandler(config *config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config.hostsToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPut {
+			w.Header().Set("Allow", http.MethodPut)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !hasBearerToken(r, config.hostsToken) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req hostsRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("malformed JSON: %v", err),
+				http.StatusBadRequest)
+			return
+		}
+		hosts, err := parseHostsRequest(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		config.setHosts(hosts)
+		log.Printf("Hosts replaced with %v hosts via API", len(hosts))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
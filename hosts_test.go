@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestValidateHost(t *testing.T) {
+	cases := []struct {
+		host string
+		ok   bool
+	}{
+		{"www.example.com", true},
+		{"www.example.com:8443", true},
+		{"192.0.2.1", true},
+		{"[2001:db8::1]:443", true},
+		{"例え.jp", true},
+		{"例え.jp:8443", true},
+		{"bücher.example", true},
+		{"", false},
+		{"www.example.com:0", false},
+		{"www.example.com:https", false},
+		{"www example.com", false},
+		{"www.example.com/path", false},
+	}
+	for _, c := range cases {
+		if err := validateHost(c.host); (err == nil) != c.ok {
+			t.Errorf("validateHost(%q) = %v, want ok %v", c.host, err, c.ok)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Time to wait for in-flight requests on shutdown.
+const httpShutdownTimeout = 10 * time.Second
+
+// Handlers of HTTP endpoints.
+func httpHandler(config *config, status *cycleStatus, runner *cycleRunner) http.Handler {
+	mux := http.NewServeMux()
+	// Not protected so that kubelet can probe liveness without credentials.
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !status.healthy(time.Now(), config.interval) {
+			http.Error(w, "unhealthy", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.Handle("/ready", config.requireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !status.ready() {
+			http.Error(w, "first check not finished",
+				http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})))
+	mux.Handle("/metrics", config.requireAuth(metricsHandler(config, status)))
+	// Protected by HOSTS_API_TOKEN instead, so that hosts can be replaced
+	// by a deploy pipeline which doesn't share the dashboard credentials.
+	mux.Handle("/hosts", hostsHandler(config))
+	mux.Handle("/status", config.requireAuth(statusHandler(config, status)))
+	mux.Handle("/top", config.requireAuth(topHandler(config, status)))
+	mux.Handle("/events", config.requireAuth(eventsHandler(status)))
+	// Protected by signatures with AGENT_SECRET instead, which agents
+	// share with the central instance rather than HTTP credentials.
+	mux.Handle("/agent/results", agentResultsHandler(config))
+	// Protected by SENDGRID_WEBHOOK_TOKEN instead, since SendGrid can't
+	// send an Authorization header but can put a token in the URL.
+	mux.Handle("/sendgrid/events", sendgridEventsHandler(config, status))
+	mux.Handle("/probe", config.requireAuth(probeHandler(config, status)))
+	mux.Handle("/check", config.requireAuth(checkHandler(config, runner)))
+	mux.Handle("/host/", config.requireAuth(hostHandler(config, status)))
+	mux.Handle("/badge/", config.requireAuth(badgeHandler(config, status)))
+	mux.Handle("/assets/", config.requireAuth(http.FileServer(http.FS(assets))))
+	mux.Handle("/", config.requireAuth(dashboardHandler(config, status)))
+	return mux
+}
+
+// Whether a request has credentials of HTTP_AUTH_TOKEN or
+// HTTP_AUTH_USER and HTTP_AUTH_PASSWORD.
+func (config *config) authorized(r *http.Request) bool {
+	if config.httpAuthToken != "" && hasBearerToken(r, config.httpAuthToken) {
+		return true
+	}
+	if config.httpAuthUser == "" {
+		return false
+	}
+	user, password, ok := r.BasicAuth()
+	return ok &&
+		subtle.ConstantTimeCompare([]byte(user), []byte(config.httpAuthUser)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(config.httpAuthPassword)) == 1
+}
+
+// Require credentials if they're configured.
+// Returns 401 without credentials and 403 with wrong ones.
+func (config *config) requireAuth(handler http.Handler) http.Handler {
+	if config.httpAuthToken == "" && config.httpAuthUser == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			if config.httpAuthToken != "" {
+				w.Header().Add("WWW-Authenticate", "Bearer")
+			}
+			if config.httpAuthUser != "" {
+				w.Header().Add("WWW-Authenticate", `Basic realm="sslreminder"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !config.authorized(r) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Start the gRPC server on GRPC_ADDR, returning a function to stop it.
+// Replaced when built with the grpc tag.
+var startGRPCServer = func(config *config, status *cycleStatus) func() {
+	if config.grpcAddr != "" {
+		log.Fatalf("GRPC_ADDR requires building with -tags grpc")
+	}
+	return func() {}
+}
+
+// Listen on HTTP_ADDR and serve HTTP endpoints in background.
+// Exit process if it can't listen.
+func startHTTPServer(config *config, status *cycleStatus,
+	runner *cycleRunner) *http.Server {
+	listener, err := net.Listen("tcp", config.httpAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %v: %v", config.httpAddr, err)
+	}
+	server := &http.Server{
+		Handler:           httpHandler(config, status, runner),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	scheme := "HTTP"
+	if config.httpTLSCert != "" || config.httpTLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(config.httpTLSCert, config.httpTLSKey)
+		if err != nil {
+			log.Fatalf("Failed to load HTTP_TLS_CERT and HTTP_TLS_KEY: %v", err)
+		}
+		server.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+		scheme = "HTTPS"
+	}
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("ERROR serving HTTP: %v", err)
+		}
+	}()
+	log.Printf("Serving %v on %v", scheme, listener.Addr())
+	return server
+}
+
+// Stop the HTTP server, waiting for in-flight requests for a while.
+func stopHTTPServer(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("ERROR shutting down HTTP server: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Routes protected by HTTP_AUTH_TOKEN and HTTP_AUTH_USER, with the status
+// expected with valid credentials. Zero skips requests with them.
+var protectedRoutes = []struct {
+	method string
+	path   string
+	code   int
+}{
+	{http.MethodGet, "/ready", http.StatusOK},
+	{http.MethodGet, "/metrics", http.StatusOK},
+	{http.MethodGet, "/status", http.StatusOK},
+	{http.MethodGet, "/top", http.StatusOK},
+	// Streams until the client disconnects.
+	{http.MethodGet, "/events", 0},
+	{http.MethodGet, "/probe", http.StatusBadRequest},
+	{http.MethodPost, "/check", http.StatusAccepted},
+	{http.MethodGet, "/host/www.example.com", http.StatusNotFound},
+	{http.MethodGet, "/badge/www.example.com.svg", http.StatusNotFound},
+	{http.MethodGet, "/assets/", http.StatusOK},
+	{http.MethodGet, "/", http.StatusOK},
+}
+
+func newTestHandler(config *config) http.Handler {
+	config.location = time.UTC
+	config.topN = 10
+	config.interval = time.Hour
+	status := newCycleStatus(time.Now())
+	status.finish(time.Now(), false)
+	runner := newCycleRunner(func(req *cycleRequest, now time.Time) {})
+	return httpHandler(config, status, runner)
+}
+
+func serveTest(handler http.Handler, method, path string, auth func(*http.Request)) int {
+	req := httptest.NewRequest(method, path, nil)
+	if auth != nil {
+		auth(req)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRequireAuthToken(t *testing.T) {
+	handler := newTestHandler(&config{httpAuthToken: "secret"})
+	cases := []struct {
+		name string
+		auth func(*http.Request)
+		code int
+	}{
+		{"no credentials", nil, http.StatusUnauthorized},
+		{"wrong token", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer wrong")
+		}, http.StatusForbidden},
+		{"token without Bearer", func(r *http.Request) {
+			r.Header.Set("Authorization", "secret")
+		}, http.StatusForbidden},
+		{"token with another scheme", func(r *http.Request) {
+			r.Header.Set("Authorization", "Token secret")
+		}, http.StatusForbidden},
+	}
+	for _, route := range protectedRoutes {
+		for _, c := range cases {
+			if code := serveTest(handler, route.method, route.path, c.auth); code != c.code {
+				t.Errorf("%v %v with %v: got %v, want %v",
+					route.method, route.path, c.name, code, c.code)
+			}
+		}
+		if route.code == 0 {
+			continue
+		}
+		code := serveTest(handler, route.method, route.path, func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer secret")
+		})
+		if code != route.code {
+			t.Errorf("%v %v with token: got %v, want %v",
+				route.method, route.path, code, route.code)
+		}
+	}
+}
+
+func TestRequireAuthBasic(t *testing.T) {
+	handler := newTestHandler(&config{httpAuthUser: "user", httpAuthPassword: "password"})
+	for _, route := range protectedRoutes {
+		if code := serveTest(handler, route.method, route.path, nil); code != http.StatusUnauthorized {
+			t.Errorf("%v %v without credentials: got %v, want 401",
+				route.method, route.path, code)
+		}
+		code := serveTest(handler, route.method, route.path, func(r *http.Request) {
+			r.SetBasicAuth("user", "wrong")
+		})
+		if code != http.StatusForbidden {
+			t.Errorf("%v %v with wrong password: got %v, want 403",
+				route.method, route.path, code)
+		}
+		if route.code == 0 {
+			continue
+		}
+		code = serveTest(handler, route.method, route.path, func(r *http.Request) {
+			r.SetBasicAuth("user", "password")
+		})
+		if code != route.code {
+			t.Errorf("%v %v with password: got %v, want %v",
+				route.method, route.path, code, route.code)
+		}
+	}
+}
+
+func TestHealthzWithoutCredentials(t *testing.T) {
+	handler := newTestHandler(&config{httpAuthToken: "secret"})
+	if code := serveTest(handler, http.MethodGet, "/healthz", nil); code != http.StatusOK {
+		t.Errorf("got %v, want 200", code)
+	}
+}
+
+func TestHasBearerToken(t *testing.T) {
+	cases := []struct {
+		header string
+		want   bool
+	}{
+		{"Bearer secret", true},
+		{"secret", false},
+		{"bearer secret", false},
+		{"Basic secret", false},
+		{"Bearer wrong", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/hosts", nil)
+		if c.header != "" {
+			r.Header.Set("Authorization", c.header)
+		}
+		if got := hasBearerToken(r, "secret"); got != c.want {
+			t.Errorf("hasBearerToken(%q) = %v, want %v", c.header, got, c.want)
+		}
+	}
+}
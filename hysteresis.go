@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// The threshold of a host in effect. Hosts already expiring soon stay
+// so until they're beyond their thresholds plus HYSTERESIS_DAYS,
+// so that hosts near the thresholds don't flap between reminders.
+func (config *config) effectiveThreshold(host string, r *result) int {
+	days, _ := config.thresholdOf(host, r)
+	if config.hysteresisDays <= 0 {
+		return days
+	}
+	config.mu.Lock()
+	defer config.mu.Unlock()
+	if config.soonHosts[host] {
+		days += config.hysteresisDays
+	}
+	return days
+}
+
+// Update hosts expiring soon by results of a cycle. Hosts without
+// results keep their last states. They're seeded from STATE_FILE
+// in the first cycle.
+func (config *config) updateSoon(exMap map[string]*result, now time.Time) {
+	if config.hysteresisDays <= 0 {
+		return
+	}
+	config.mu.Lock()
+	seeded := config.soonHosts != nil
+	config.mu.Unlock()
+	if !seeded {
+		soon := make(map[string]bool)
+		if config.stateFile != "" {
+			s, err := loadState(config.stateFile)
+			if err != nil {
+				log.Printf("ERROR loading state: %v", err)
+			} else if s != nil {
+				for host, h := range s.Hosts {
+					soon[host] = h.Tier != tierOK
+				}
+			}
+		}
+		config.mu.Lock()
+		config.soonHosts = soon
+		config.mu.Unlock()
+	}
+
+	updated := make(map[string]bool, len(exMap))
+	for host, r := range exMap {
+		updated[host] = config.within(r.notAfter, now, config.effectiveThreshold(host, r))
+	}
+	config.mu.Lock()
+	defer config.mu.Unlock()
+	for host, soon := range updated {
+		if config.soonHosts[host] && !soon {
+			log.Printf("%v is beyond its threshold plus %v days of hysteresis",
+				host, config.hysteresisDays)
+		}
+		config.soonHosts[host] = soon
+	}
+}
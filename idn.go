@@ -0,0 +1,53 @@
+package main
+
+import (
+	"golang.org/x/net/idna"
+	"net"
+	"strings"
+)
+
+// The ASCII form of a host name to connect to and send via SNI, with
+// internationalized labels converted to punycode like
+// xn--bcher-kva.example. Names already in ASCII and IP addresses are
+// returned as is. The name is returned as is with an error if it can't
+// be converted.
+func asciiHost(name string) (string, error) {
+	if isASCII(name) || net.ParseIP(name) != nil {
+		return name, nil
+	}
+	ascii, err := idna.Lookup.ToASCII(name)
+	if err != nil {
+		return name, err
+	}
+	return ascii, nil
+}
+
+// The Unicode form of a host name or host:port to show in reports, like
+// bücher.example. The name is returned as is if it has no punycode
+// labels or they're malformed.
+func unicodeHost(name string) string {
+	if !strings.Contains(strings.ToLower(name), "xn--") {
+		return name
+	}
+	host, port, err := net.SplitHostPort(name)
+	if err != nil {
+		host, port = name, ""
+	}
+	unicode, err := idna.Lookup.ToUnicode(host)
+	if err != nil {
+		return name
+	}
+	if port != "" {
+		return net.JoinHostPort(unicode, port)
+	}
+	return unicode
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Read host specs in HOSTS_FILE, one per line.
+// Blank lines and lines starting with # are ignored.
+func readHostsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var specs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		specs = append(specs, line)
+	}
+	return specs, scanner.Err()
+}
+
+// Hosts monitored at some point, persisted in INVENTORY_SNAPSHOT.
+type inventorySnapshot struct {
+	TakenAt time.Time `json:"taken_at"`
+	Hosts   []string  `json:"hosts"`
+}
+
+// Read a snapshot. Nil without error if the file doesn't exist yet.
+func loadInventorySnapshot(path string) (*inventorySnapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s inventorySnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", path, err)
+	}
+	return &s, nil
+}
+
+// Hosts in a but not in b, sorted.
+func missingHosts(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, host := range b {
+		inB[host] = true
+	}
+	var missing []string
+	for _, host := range a {
+		if !inB[host] {
+			missing = append(missing, host)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Compare hosts of HOSTS and HOSTS_FILE with INVENTORY_SNAPSHOT like
+// "sslreminder inventory", printing added and removed hosts.
+// It exits with 1 if hosts were removed other than --expect-removed,
+// keeping the snapshot until they're expected. Otherwise the snapshot
+// is updated.
+func inventoryCommand(args []string) {
+	flags := flag.NewFlagSet("inventory", flag.ExitOnError)
+	expected := flags.String("expect-removed", "", "comma separated hosts expected to be removed")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		log.Fatalf("Usage: sslreminder inventory [--expect-removed <host>,...]")
+	}
+	path := envMandatory("INVENTORY_SNAPSHOT")
+	var current []string
+	for _, t := range readHosts() {
+		current = append(current, t.host)
+	}
+	sort.Strings(current)
+
+	prev, err := loadInventorySnapshot(path)
+	if err != nil {
+		log.Fatalf("Failed to load INVENTORY_SNAPSHOT: %v", err)
+	}
+	unexpected := 0
+	if prev == nil {
+		fmt.Printf("No snapshot yet, recording %v hosts\n", len(current))
+	} else {
+		for _, host := range missingHosts(current, prev.Hosts) {
+			fmt.Printf("+ %v\n", host)
+		}
+		expectedHosts := splitOptional(*expected)
+		for _, host := range missingHosts(prev.Hosts, current) {
+			if contains(expectedHosts, host) {
+				fmt.Printf("- %v (expected)\n", host)
+				continue
+			}
+			fmt.Printf("- %v\n", host)
+			unexpected++
+		}
+	}
+	if unexpected > 0 {
+		log.Printf("%v hosts were removed since %v; pass them to --expect-removed if intended",
+			unexpected, prev.TakenAt)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(inventorySnapshot{time.Now(), current}, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode snapshot: %v", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		log.Fatalf("Failed to write INVENTORY_SNAPSHOT: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A host whose certificate expired since the previous cycle.
+type justExpiredReport struct {
+	Host     string    `json:"host"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// Hosts valid in the previous cycle in CYCLE_FILE and expired now,
+// sorted by hosts. Hosts failing either check are not included.
+func justExpiredHosts(prev *cycleSnapshot, targets []target,
+	exMap map[string]*result, now time.Time) []justExpiredReport {
+	var expired []justExpiredReport
+	for _, t := range targets {
+		r, ok := exMap[t.host]
+		if !ok || !r.notAfter.Before(now) {
+			continue
+		}
+		before, ok := prev.Hosts[t.host]
+		if !ok || before.NotAfter == nil || !before.NotAfter.After(prev.FinishedAt) {
+			continue
+		}
+		expired = append(expired, justExpiredReport{t.host, r.notAfter})
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].Host < expired[j].Host
+	})
+	return expired
+}
+
+// The section of hosts just expired in mails, which comes first.
+func justExpiredText(rem *reminder) string {
+	if len(rem.JustExpired) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	buf.WriteString("OUTAGE: certificates of following hosts expired since last check:\n")
+	for _, e := range rem.JustExpired {
+		buf.WriteString(fmt.Sprintf("%v: expired at %v\n",
+			e.Host, e.NotAfter.UTC().Format(time.RFC3339)))
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+
+	keystore "github.com/pavlo-v-chernykh/keystore-go/v4"
+	"gopkg.in/yaml.v3"
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+// A keystore file whose certificates are checked, like a JKS file.
+type keystoreFile struct {
+	path string
+	// One of pkcs12, jks and kubeconfig.
+	kind string
+	// The env var holding the password of the store.
+	passwordEnv string
+}
+
+// Discovers certificates in keystore files.
+// Each entry of a store is a target named like /etc/app/keystore.jks#alias.
+type keystoreSource struct {
+	files []keystoreFile
+}
+
+// Read KEYSTORES like /etc/app/keystore.jks;password_env=JKS_PASSWORD.
+// Returns nil if it's not set.
+func readKeystoreSource() *keystoreSource {
+	specs := splitOptional(envOptional("KEYSTORES", ""))
+	if len(specs) == 0 {
+		return nil
+	}
+	var files []keystoreFile
+	for _, spec := range specs {
+		f, err := parseKeystoreFile(spec)
+		if err != nil {
+			log.Fatalf("Failed to parse KEYSTORES: %v", err)
+		}
+		files = append(files, f)
+	}
+	return &keystoreSource{files}
+}
+
+// Parse a keystore in KEYSTORES. The type is inferred from the extension
+// unless given like type=jks.
+func parseKeystoreFile(spec string) (keystoreFile, error) {
+	fields := strings.Split(strings.TrimSpace(spec), ";")
+	f := keystoreFile{path: fields[0]}
+	if len(f.path) == 0 {
+		return f, fmt.Errorf("Empty path in %q", spec)
+	}
+	for _, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return f, fmt.Errorf("Malformed option %q in %q", field, spec)
+		}
+		switch kv[0] {
+		case "type":
+			f.kind = kv[1]
+		case "password_env":
+			f.passwordEnv = kv[1]
+		default:
+			return f, fmt.Errorf("Unknown option %q in %q", kv[0], spec)
+		}
+	}
+	if f.kind == "" {
+		switch strings.ToLower(filepath.Ext(f.path)) {
+		case ".p12", ".pfx":
+			f.kind = "pkcs12"
+		case ".jks":
+			f.kind = "jks"
+		default:
+			return f, fmt.Errorf("Unknown type of %v, give it like type=jks", f.path)
+		}
+	}
+	switch f.kind {
+	case "pkcs12", "jks", "kubeconfig":
+	default:
+		return f, fmt.Errorf("Unknown type %q of %v", f.kind, f.path)
+	}
+	return f, nil
+}
+
+func (k *keystoreSource) name() string {
+	return "keystore"
+}
+
+// Targets of entries of all stores.
+// A store failing to be read is logged and skipped.
+func (k *keystoreSource) discover() ([]target, error) {
+	var targets []target
+	for _, f := range k.files {
+		entries, err := f.read()
+		if err != nil {
+			log.Printf("ERROR reading keystore %v: %v", f.path, err)
+			continue
+		}
+		for _, e := range entries {
+			targets = append(targets, target{
+				host:   f.path + "#" + e.alias,
+				source: "keystore " + f.path,
+				certs:  e.chain,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// An entry of a keystore.
+type keystoreEntry struct {
+	alias string
+	// Certificates of the entry, leaf first.
+	chain []*x509.Certificate
+}
+
+// Read entries of a keystore.
+func (f keystoreFile) read() ([]keystoreEntry, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	password := ""
+	if f.passwordEnv != "" {
+		password = getenv(f.passwordEnv)
+	}
+	switch f.kind {
+	case "pkcs12":
+		return readPKCS12(data, password)
+	case "jks":
+		return readJKS(data, password)
+	default:
+		return readKubeconfig(data)
+	}
+}
+
+// Read a PKCS#12 file having a key and its chain, or trusted certificates
+// only. Entries are named after the common names of certificates.
+func readPKCS12(data []byte, password string) ([]keystoreEntry, error) {
+	_, cert, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err == nil {
+		chain := append([]*x509.Certificate{cert}, caCerts...)
+		return []keystoreEntry{{cert.Subject.CommonName, chain}}, nil
+	}
+	certs, trustErr := pkcs12.DecodeTrustStore(data, password)
+	if trustErr != nil {
+		return nil, err
+	}
+	var entries []keystoreEntry
+	for _, cert := range certs {
+		entries = append(entries, keystoreEntry{
+			cert.Subject.CommonName, []*x509.Certificate{cert}})
+	}
+	return entries, nil
+}
+
+// Read private key and trusted certificate entries of a JKS file.
+func readJKS(data []byte, password string) ([]keystoreEntry, error) {
+	ks := keystore.New()
+	if err := ks.Load(bytes.NewReader(data), []byte(password)); err != nil {
+		return nil, err
+	}
+	var entries []keystoreEntry
+	for _, alias := range ks.Aliases() {
+		var raws []keystore.Certificate
+		switch {
+		case ks.IsPrivateKeyEntry(alias):
+			chain, err := ks.GetPrivateKeyEntryCertificateChain(alias)
+			if err != nil {
+				return nil, err
+			}
+			raws = chain
+		case ks.IsTrustedCertificateEntry(alias):
+			entry, err := ks.GetTrustedCertificateEntry(alias)
+			if err != nil {
+				return nil, err
+			}
+			raws = []keystore.Certificate{entry.Certificate}
+		default:
+			continue
+		}
+		var chain []*x509.Certificate
+		for _, raw := range raws {
+			cert, err := x509.ParseCertificate(raw.Content)
+			if err != nil {
+				return nil, fmt.Errorf("Malformed certificate of %v: %v", alias, err)
+			}
+			chain = append(chain, cert)
+		}
+		if len(chain) > 0 {
+			entries = append(entries, keystoreEntry{alias, chain})
+		}
+	}
+	return entries, nil
+}
+
+// Parts of a kubeconfig having certificates.
+type kubeconfig struct {
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			ClientCertificateData string `yaml:"client-certificate-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// Read CAs of clusters and client certificates of users embedded in
+// a kubeconfig, named like cluster/prod and user/admin.
+// Certificates referred to by paths are not read.
+func readKubeconfig(data []byte) ([]keystoreEntry, error) {
+	var kc kubeconfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, err
+	}
+	var entries []keystoreEntry
+	add := func(alias, encoded string) error {
+		if encoded == "" {
+			return nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("Malformed certificate of %v: %v", alias, err)
+		}
+		chain, err := parsePEMCertificates(decoded)
+		if err != nil {
+			return fmt.Errorf("Malformed certificate of %v: %v", alias, err)
+		}
+		if len(chain) > 0 {
+			entries = append(entries, keystoreEntry{alias, chain})
+		}
+		return nil
+	}
+	for _, c := range kc.Clusters {
+		if err := add("cluster/"+c.Name, c.Cluster.CertificateAuthorityData); err != nil {
+			return nil, err
+		}
+	}
+	for _, u := range kc.Users {
+		if err := add("user/"+u.Name, u.User.ClientCertificateData); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Parse certificates in PEM, skipping other blocks.
+func parsePEMCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The least handshakes of a host in HISTORY_FILE to compare one with
+// their median.
+const minLatencySamples = 5
+
+// The median of values, which must not be empty.
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	m := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		m = (sorted[len(sorted)/2-1] + m) / 2
+	}
+	return m
+}
+
+// The median handshake duration of a host within RELIABILITY_WINDOW.
+// Nil unless it has enough samples.
+func (h *history) medianHandshake(host string) *time.Duration {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var samples []float64
+	for _, o := range h.outcomes[host] {
+		if o.handshake > 0 {
+			samples = append(samples, o.handshake.Seconds())
+		}
+	}
+	if len(samples) < minLatencySamples {
+		return nil
+	}
+	m := time.Duration(median(samples) * float64(time.Second))
+	return &m
+}
+
+// Warnings of checks whose handshakes took longer than LATENCY_CEILING
+// or LATENCY_MEDIAN_MULTIPLE times their medians in HISTORY_FILE.
+// Both are disabled by default.
+func (config *config) slowHandshakes(checks []*hostCheck) []string {
+	var warnings []string
+	for _, c := range checks {
+		if c.result == nil || c.result.handshake == 0 {
+			continue
+		}
+		handshake := c.result.handshake
+		if config.latencyCeiling > 0 && handshake > config.latencyCeiling {
+			warnings = append(warnings, fmt.Sprintf("%v: handshake took %v, over %v",
+				c.target.host, handshake, config.latencyCeiling))
+			continue
+		}
+		if config.latencyMultiple <= 0 {
+			continue
+		}
+		if m := config.history.medianHandshake(c.target.host); m != nil &&
+			handshake > *m*time.Duration(config.latencyMultiple) {
+			warnings = append(warnings, fmt.Sprintf("%v: handshake took %v, %.1f times the median %v",
+				c.target.host, handshake, handshake.Seconds()/m.Seconds(), *m))
+		}
+	}
+	return warnings
+}
+
+// Text of latency warnings of a reminder, apart from certificates.
+func latencyWarningsText(rem *reminder) string {
+	if len(rem.LatencyWarnings) == 0 {
+		return ""
+	}
+	return "TLS handshakes of following hosts are slow. Their certificates are not affected:\n" +
+		strings.Join(rem.LatencyWarnings, "\n") + "\n\n"
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Read ISSUER_LIFETIMES like "Let's Encrypt=90,ZeroSSL=90", the typical
+// validity of certificates issued by them.
+// Exit process if it's malformed.
+func readIssuerLifetimes() []issuerDays {
+	return readIssuerDays("ISSUER_LIFETIMES", 1)
+}
+
+// Why a certificate is overdue for renewal by ISSUER_LIFETIMES, with its
+// age and the expected renewal point at RENEWAL_POINT_PERCENT of the
+// lifetime of its issuer, or "" if it isn't.
+func (config *config) overdueRenewal(r *result, now time.Time) string {
+	if len(config.issuerLifetimes) == 0 || len(r.chain) == 0 || r.notBefore.IsZero() {
+		return ""
+	}
+	issuer := r.chain[0].Issuer.String()
+	for _, l := range config.issuerLifetimes {
+		if !strings.Contains(issuer, l.issuer) {
+			continue
+		}
+		age := now.Sub(r.notBefore).Hours() / 24
+		renewal := float64(l.days) * float64(config.renewalPointPercent) / 100
+		if age <= renewal {
+			return ""
+		}
+		return fmt.Sprintf("%.0f days old, expected to be renewed at %.0f days of %v days of %v",
+			math.Floor(age), renewal, l.days, l.issuer)
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// List results of the last cycle in CYCLE_FILE like "sslreminder list",
+// with success rates if HISTORY_FILE is set.
+// Exit process on errors.
+func listCommand(args []string) {
+	if len(args) != 0 {
+		log.Fatalf("Usage: sslreminder list")
+	}
+	path := envMandatory("CYCLE_FILE")
+	snapshot, err := loadCycleSnapshot(path)
+	if err != nil {
+		log.Fatalf("Failed to load CYCLE_FILE: %v", err)
+	}
+	if snapshot == nil {
+		log.Fatalf("No cycle in %v yet", path)
+	}
+	history := readHistory()
+
+	hosts := make([]string, 0, len(snapshot.Hosts))
+	for host := range snapshot.Hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tTIER\tNOT AFTER\tSUCCESS RATE")
+	for _, host := range hosts {
+		h := snapshot.Hosts[host]
+		tierName, notAfter := h.Tier.String(), "-"
+		if h.Error != "" {
+			tierName = "error"
+		} else {
+			notAfter = h.NotAfter.Format(time.RFC3339)
+		}
+		rate := "-"
+		if r := history.successRate(host, now); r != nil {
+			rate = fmt.Sprintf("%.1f%%", *r*100)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", host, tierName, notAfter, rate)
+	}
+	w.Flush()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"gopkg.in/natefinch/lumberjack.v2"
+	"log"
+)
+
+// Prefix log messages with the name of the checker, and direct log
+// output to LOG_FILE if it's set.
+// The file is rotated when it grows beyond LOG_MAX_SIZE_MB and
+// at most LOG_MAX_BACKUPS old files are kept, so disk usage stays bounded.
+func setupLog() {
+	checkerName = readCheckerName()
+	if checkerName != "" {
+		log.SetPrefix("[" + checkerName + "] ")
+		log.SetFlags(log.Flags() | log.Lmsgprefix)
+	}
+	filename := envOptional("LOG_FILE", "")
+	if len(filename) == 0 {
+		return
+	}
+	log.SetOutput(&lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    envOptionalInt("LOG_MAX_SIZE_MB", 100),
+		MaxBackups: envOptionalInt("LOG_MAX_BACKUPS", 3),
+	})
+}
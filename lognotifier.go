@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Levels selectable by LOG_REMINDERS.
+var logReminderLevels = []string{"info", "warning", "error"}
+
+// Writes reminders as single JSON lines to the log for log-based alerting.
+type logNotifier struct {
+	level string
+}
+
+// A line written by logNotifier.
+type reminderLogLine struct {
+	Time     time.Time `json:"time"`
+	Level    string    `json:"level"`
+	Message  string    `json:"msg"`
+	Reminder *reminder `json:"reminder"`
+}
+
+// Read LOG_REMINDERS. Nil if it's not set. Exit process if it's unknown.
+func readLogNotifier() *logNotifier {
+	level := envOptional("LOG_REMINDERS", "")
+	if level == "" {
+		return nil
+	}
+	if !contains(logReminderLevels, level) {
+		log.Fatalf("Unknown LOG_REMINDERS %q, expected info, warning or error", level)
+	}
+	return &logNotifier{level}
+}
+
+func (l *logNotifier) name() string {
+	return "log"
+}
+
+func (l *logNotifier) recipients() string {
+	return "the log"
+}
+
+// Write the reminder in JSON as is, without the prefix of log lines,
+// to the output of the log like LOG_FILE.
+func (l *logNotifier) notify(rem *reminder) error {
+	line, err := json.Marshal(reminderLogLine{time.Now(), l.level, rem.Subject, rem})
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(log.Writer(), string(line)); err != nil {
+		return fmt.Errorf("writing to the log: %v", err)
+	}
+	return nil
+}
+
+// The log is always writable.
+func (l *logNotifier) ping() error {
+	return nil
+}
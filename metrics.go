@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Upper bounds in seconds of buckets of check durations.
+var checkDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// A cumulative histogram like Prometheus's.
+type histogram struct {
+	bounds []float64
+	counts []int
+	sum    float64
+	count  int
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]int, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Write a histogram in the Prometheus text format.
+func (h *histogram) write(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %v %v\n", name, help)
+	fmt.Fprintf(w, "# TYPE %v histogram\n", name)
+	for i, bound := range h.bounds {
+		fmt.Fprintf(w, "%v_bucket{le=\"%v\"} %v\n", name, bound, h.counts[i])
+	}
+	fmt.Fprintf(w, "%v_bucket{le=\"+Inf\"} %v\n", name, h.count)
+	fmt.Fprintf(w, "%v_sum %v\n", name, h.sum)
+	fmt.Fprintf(w, "%v_count %v\n", name, h.count)
+}
+
+// Sorted keys of a map from hosts.
+func sortedHosts(hosts map[string]*hostCheck) []string {
+	keys := make([]string, 0, len(hosts))
+	for host := range hosts {
+		keys = append(keys, host)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Whether a cipher suite is known to be insecure.
+func insecureCipherSuite(id uint16) bool {
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Write the negotiated TLS version and cipher suite of hosts as info metrics.
+// Each host has one series of each, so cardinality is bounded by hosts.
+func (s *cycleStatus) writeTLSInfoMetrics(w io.Writer, hosts []string) {
+	fmt.Fprintln(w, "# HELP ssl_tls_version Negotiated TLS version.")
+	fmt.Fprintln(w, "# TYPE ssl_tls_version gauge")
+	for _, host := range hosts {
+		if r := s.hosts[host].result; r != nil && r.tlsVersion != 0 {
+			fmt.Fprintf(w, "ssl_tls_version{host=%q,version=%q} 1\n",
+				host, tls.VersionName(r.tlsVersion))
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP ssl_tls_cipher Negotiated cipher suite, and whether it's secure.")
+	fmt.Fprintln(w, "# TYPE ssl_tls_cipher gauge")
+	for _, host := range hosts {
+		if r := s.hosts[host].result; r != nil && r.tlsVersion != 0 {
+			fmt.Fprintf(w, "ssl_tls_cipher{host=%q,cipher=%q,secure=\"%v\"} 1\n",
+				host, tls.CipherSuiteName(r.cipherSuite),
+				!insecureCipherSuite(r.cipherSuite))
+		}
+	}
+}
+
+// Write metrics in the Prometheus text format.
+// They are taken from the latest checks in memory.
+func (s *cycleStatus) writeMetrics(w io.Writer, now time.Time, tlsInfo, lifetime bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hosts := sortedHosts(s.hosts)
+
+	fmt.Fprintln(w, "# HELP ssl_cert_not_after_timestamp_seconds Expiration of the certificate.")
+	fmt.Fprintln(w, "# TYPE ssl_cert_not_after_timestamp_seconds gauge")
+	for _, host := range hosts {
+		if r := s.hosts[host].result; r != nil {
+			fmt.Fprintf(w, "ssl_cert_not_after_timestamp_seconds{host=%q} %v\n",
+				host, r.notAfter.Unix())
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP ssl_cert_days_remaining Days until the certificate expires.")
+	fmt.Fprintln(w, "# TYPE ssl_cert_days_remaining gauge")
+	for _, host := range hosts {
+		if r := s.hosts[host].result; r != nil {
+			fmt.Fprintf(w, "ssl_cert_days_remaining{host=%q} %.2f\n",
+				host, secondsBetween(now, r.notAfter)/(24*60*60))
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP ssl_cert_seconds_until_expiry Seconds until the certificate expires at the scrape, negative if expired.")
+	fmt.Fprintln(w, "# TYPE ssl_cert_seconds_until_expiry gauge")
+	for _, host := range hosts {
+		if r := s.hosts[host].result; r != nil {
+			fmt.Fprintf(w, "ssl_cert_seconds_until_expiry{host=%q} %.0f\n",
+				host, secondsBetween(now, r.notAfter))
+		}
+	}
+
+	if lifetime {
+		fmt.Fprintln(w, "# HELP ssl_cert_remaining_lifetime_ratio Ratio of the validity period remaining.")
+		fmt.Fprintln(w, "# TYPE ssl_cert_remaining_lifetime_ratio gauge")
+		for _, host := range hosts {
+			if r := s.hosts[host].result; r != nil {
+				if percent := remainingPercent(r, now); percent != nil {
+					fmt.Fprintf(w, "ssl_cert_remaining_lifetime_ratio{host=%q} %.4f\n",
+						host, *percent/100)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP ssl_check_success Whether the last check of the host succeeded.")
+	fmt.Fprintln(w, "# TYPE ssl_check_success gauge")
+	for _, host := range hosts {
+		success := 0
+		if s.hosts[host].err == nil {
+			success = 1
+		}
+		fmt.Fprintf(w, "ssl_check_success{host=%q} %v\n", host, success)
+	}
+
+	fmt.Fprintln(w, "# HELP ssl_check_cycles_total Finished check cycles.")
+	fmt.Fprintln(w, "# TYPE ssl_check_cycles_total counter")
+	fmt.Fprintf(w, "ssl_check_cycles_total %v\n", s.cycles)
+
+	fmt.Fprintln(w, "# HELP ssl_check_errors_total Errors of checks by class.")
+	fmt.Fprintln(w, "# TYPE ssl_check_errors_total counter")
+	for _, host := range hosts {
+		classes := make([]string, 0, len(s.hostErrors[host]))
+		for class := range s.hostErrors[host] {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			fmt.Fprintf(w, "ssl_check_errors_total{host=%q,class=%q} %v\n",
+				host, class, s.hostErrors[host][class])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP ssl_notifications_total Notifications by notifier and result.")
+	fmt.Fprintln(w, "# TYPE ssl_notifications_total counter")
+	notifiers := make([]string, 0, len(s.notifications))
+	for notifier := range s.notifications {
+		notifiers = append(notifiers, notifier)
+	}
+	sort.Strings(notifiers)
+	for _, notifier := range notifiers {
+		counts := s.notifications[notifier]
+		fmt.Fprintf(w, "ssl_notifications_total{notifier=%q,result=\"sent\"} %v\n",
+			notifier, counts[true])
+		fmt.Fprintf(w, "ssl_notifications_total{notifier=%q,result=\"failed\"} %v\n",
+			notifier, counts[false])
+	}
+
+	fmt.Fprintln(w, "# HELP ssl_mail_undelivered_total Mails undelivered by SendGrid events.")
+	fmt.Fprintln(w, "# TYPE ssl_mail_undelivered_total counter")
+	events := make([]string, 0, len(s.bounceCounts))
+	for event := range s.bounceCounts {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	for _, event := range events {
+		fmt.Fprintf(w, "ssl_mail_undelivered_total{event=%q} %v\n", event, s.bounceCounts[event])
+	}
+
+	if tlsInfo {
+		s.writeTLSInfoMetrics(w, hosts)
+	}
+
+	s.durations.write(w, "ssl_check_duration_seconds", "Durations of checks of hosts.")
+	s.handshakes.write(w, "ssl_handshake_duration_seconds", "Durations of TLS handshakes with hosts.")
+}
+
+// Serve metrics for Prometheus.
+func metricsHandler(config *config, status *cycleStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		var buf bytes.Buffer
+		status.writeMetrics(&buf, time.Now(), config.tlsInfo, config.lifetimeMetrics)
+		if config.reliabilityMetrics && config.history != nil {
+			config.history.writeMetrics(&buf, time.Now())
+		}
+		metrics := buf.Bytes()
+		if checkerName != "" {
+			metrics = labelMetrics(metrics, "checker", checkerName)
+		}
+		w.Write(metrics)
+	}
+}
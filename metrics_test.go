@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecondsUntilExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	status := newCycleStatus(now)
+	notBefore := now.AddDate(0, 0, -1)
+	status.record([]*hostCheck{
+		{target: target{host: "www.example.com"}, checkedAt: now,
+			result: &result{notBefore: notBefore, notAfter: now.Add(90 * time.Second)}},
+		{target: target{host: "forever.example.com"}, checkedAt: now,
+			result: &result{notBefore: notBefore, notAfter: now.AddDate(1000, 0, 0)}},
+	}, nil)
+	var buf bytes.Buffer
+	status.writeMetrics(&buf, now, false, false)
+	metrics := buf.String()
+	if !strings.Contains(metrics, `ssl_cert_seconds_until_expiry{host="www.example.com"} 90`+"\n") {
+		t.Errorf("seconds of www.example.com not in %v", metrics)
+	}
+	// Beyond about 292 years, where time.Duration saturates.
+	want := now.AddDate(1000, 0, 0).Unix() - now.Unix()
+	if !strings.Contains(metrics, `ssl_cert_seconds_until_expiry{host="forever.example.com"} `+
+		strconv.FormatInt(want, 10)+"\n") {
+		t.Errorf("seconds of forever.example.com not %v in %v", want, metrics)
+	}
+}
+
+// The value of a metric of a host in the Prometheus text format.
+func metricValue(t *testing.T, metrics, name, host string) float64 {
+	prefix := fmt.Sprintf("%v{host=%q} ", name, host)
+	for _, line := range strings.Split(metrics, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			v, err := strconv.ParseFloat(strings.TrimPrefix(line, prefix), 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return v
+		}
+	}
+	t.Fatalf("%v of %v not in %v", name, host, metrics)
+	return 0
+}
+
+func TestDaysRemainingOfYear9999(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+	status := newCycleStatus(now)
+	status.record([]*hostCheck{
+		{target: target{host: "forever.example.com"}, checkedAt: now,
+			result: &result{notBefore: now.AddDate(0, 0, -1), notAfter: notAfter}},
+	}, nil)
+	var buf bytes.Buffer
+	status.writeMetrics(&buf, now, false, false)
+	days := metricValue(t, buf.String(), "ssl_cert_days_remaining", "forever.example.com")
+	seconds := metricValue(t, buf.String(), "ssl_cert_seconds_until_expiry", "forever.example.com")
+	if math.Abs(days*24*60*60-seconds) > 24*60*60/100 {
+		t.Errorf("days remaining %v disagree with seconds %v", days, seconds)
+	}
+	if want := float64(notAfter.Unix()-now.Unix()) / (24 * 60 * 60); math.Abs(days-want) > 0.01 {
+		t.Errorf("days remaining: got %v, want %v", days, want)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+)
+
+// A window during which reminders of a host are muted.
+type muteWindow struct {
+	From  time.Time `json:"from"`
+	Until time.Time `json:"until"`
+}
+
+// Whether the window is active at now.
+func (w muteWindow) active(now time.Time) bool {
+	return !now.Before(w.From) && now.Before(w.Until)
+}
+
+// The window given by mute_from and mute_until tags like
+// mute_until=2024-06-03T00:00:00Z. Nil if mute_until is not set.
+// It starts immediately if mute_from is not set.
+func (t target) muteWindow() (*muteWindow, error) {
+	until, ok := t.tags["mute_until"]
+	if !ok {
+		return nil, nil
+	}
+	var w muteWindow
+	var err error
+	if w.Until, err = time.Parse(time.RFC3339, until); err != nil {
+		return nil, fmt.Errorf("Invalid mute_until %q of %v", until, t.host)
+	}
+	if from, ok := t.tags["mute_from"]; ok {
+		if w.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, fmt.Errorf("Invalid mute_from %q of %v", from, t.host)
+		}
+	}
+	return &w, nil
+}
+
+// Read windows by hosts in MUTE_FILE.
+// Returns an empty map without error if the file doesn't exist yet.
+func loadMutes(path string) (map[string]muteWindow, error) {
+	mutes := make(map[string]muteWindow)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return mutes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &mutes); err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", path, err)
+	}
+	return mutes, nil
+}
+
+// Write windows by hosts to MUTE_FILE atomically.
+func saveMutes(path string, mutes map[string]muteWindow) error {
+	data, err := json.MarshalIndent(mutes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// Reload MUTE_FILE, removing windows already ended.
+func (config *config) reloadMutes(now time.Time) {
+	if config.muteFile == "" {
+		return
+	}
+	mutes, err := loadMutes(config.muteFile)
+	if err != nil {
+		log.Printf("ERROR loading mutes: %v", err)
+		return
+	}
+	expired := false
+	for host, w := range mutes {
+		if !now.Before(w.Until) {
+			log.Printf("Mute of %v ended at %v", host, w.Until)
+			delete(mutes, host)
+			expired = true
+		}
+	}
+	if expired && config.persists() {
+		if err := saveMutes(config.muteFile, mutes); err != nil {
+			log.Printf("ERROR saving mutes: %v", err)
+		}
+	}
+	config.mu.Lock()
+	defer config.mu.Unlock()
+	config.mutes = mutes
+}
+
+// The end of the window muting a target at now, or nil if it's not muted.
+func (config *config) mutedUntil(t target, now time.Time) *time.Time {
+	var until *time.Time
+	if w, _ := t.muteWindow(); w != nil && w.active(now) {
+		until = &w.Until
+	}
+	config.mu.Lock()
+	defer config.mu.Unlock()
+	// Hosts checked per address are muted by their own names too.
+	for _, host := range []string{t.host, t.resolvedFrom} {
+		if w, ok := config.mutes[host]; ok && w.active(now) &&
+			(until == nil || w.Until.After(*until)) {
+			until = &w.Until
+		}
+	}
+	return until
+}
+
+// Mute a host like "sslreminder mute www.example.com 48h" or
+// "sslreminder mute www.example.com 2024-06-03T00:00:00Z" in MUTE_FILE.
+// Exit process on errors.
+func muteCommand(args []string) {
+	if len(args) != 2 {
+		log.Fatalf("Usage: sslreminder mute <host> <duration or RFC 3339 time>")
+	}
+	host := args[0]
+	now := time.Now()
+	w := muteWindow{From: now}
+	if d, err := time.ParseDuration(args[1]); err == nil {
+		w.Until = now.Add(d)
+	} else if w.Until, err = time.Parse(time.RFC3339, args[1]); err != nil {
+		log.Fatalf("Invalid end of mute %q", args[1])
+	}
+	if !w.Until.After(now) {
+		log.Fatalf("End of mute %v is not in the future", w.Until)
+	}
+
+	path := envMandatory("MUTE_FILE")
+	mutes, err := loadMutes(path)
+	if err != nil {
+		log.Fatalf("Failed to load mutes: %v", err)
+	}
+	mutes[host] = w
+	if err := saveMutes(path, mutes); err != nil {
+		log.Fatalf("Failed to save mutes: %v", err)
+	}
+	log.Printf("Muted %v until %v", host, w.Until)
+}
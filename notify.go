@@ -0,0 +1,653 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sendgrid/sendgrid-go"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Subject of reminders.
+const reminderSubject = "REMINDER SSL certificate expiration"
+
+// Returned by notifiers skipping a reminder on purpose, like by
+// NO_CHANGES_NO_MAIL, so that it's counted as neither sent nor failed.
+var errNotifySkipped = errors.New("skipped")
+
+// A notifier sends reminders to a channel.
+type notifier interface {
+	name() string
+	// Description of recipients like email addresses.
+	recipients() string
+	notify(rem *reminder) error
+}
+
+// A notifier which also resolves what it notified once no hosts need
+// attention, like closing an issue.
+type resolver interface {
+	resolve() error
+}
+
+// A notifier which can check that its channel is reachable and
+// authorized without sending a reminder, for "sslreminder check-notifiers".
+type pinger interface {
+	ping() error
+}
+
+// Prints reminders of a notifier instead of sending them.
+type dryRunNotifier struct {
+	notifier
+}
+
+func (d dryRunNotifier) notify(rem *reminder) error {
+	fmt.Printf("Would notify via %v to %v:\n%v\n", d.name(), d.recipients(),
+		reminderText(rem))
+	return nil
+}
+
+// The built-in text of a reminder.
+func reminderText(rem *reminder) string {
+	return justExpiredText(rem) + bouncesText(rem) + staleAgentsText(rem) + latencyWarningsText(rem) +
+		laggingPairsText(rem) + domainsText(rem) + cycleChangesText(rem) +
+		mailBody(rem.config, rem.Time, rem.targets, rem.exMap, rem.prev, rem.prevCycle) +
+		checkerText(rem)
+}
+
+// A reminder passed to notifiers.
+// Exported fields are the data given to templates and webhooks.
+type reminder struct {
+	Subject string         `json:"subject"`
+	Time    time.Time      `json:"time"`
+	Soon    []hostReport   `json:"soon"`
+	Others  []hostReport   `json:"others"`
+	Changes []changeReport `json:"changes,omitempty"`
+	// Changes since the previous cycle if CYCLE_FILE has it.
+	CycleChanges []changeReport `json:"cycle_changes,omitempty"`
+	// Agents which haven't reported within their intervals.
+	StaleAgents []string `json:"stale_agents,omitempty"`
+	// Slow TLS handshakes by LATENCY_CEILING or LATENCY_MEDIAN_MULTIPLE,
+	// which are not problems of certificates.
+	LatencyWarnings []string `json:"latency_warnings,omitempty"`
+	// Pairs of PAIRS whose followers lag behind their leaders.
+	LaggingPairs []pairReport `json:"lagging_pairs,omitempty"`
+	// Recipients previous reminders didn't reach by SendGrid events.
+	Bounces []bounceReport `json:"bounces,omitempty"`
+	// Expirations of some hosts are simulated by SIMULATE_EXPIRY.
+	Simulated bool `json:"simulated,omitempty"`
+	// Registrations of domains expiring, expired or failing lookups.
+	Domains []domainReport `json:"domains,omitempty"`
+	// Hosts unacknowledged after ESCALATE_AFTER reminders.
+	Escalated []string `json:"escalated,omitempty"`
+	// Hosts expired since the previous cycle in CYCLE_FILE.
+	JustExpired []justExpiredReport `json:"just_expired,omitempty"`
+	// The name of the checker by CHECKER_NAME.
+	Checker string `json:"checker,omitempty"`
+
+	// For the built-in email format.
+	config  *config
+	targets []target
+	exMap   map[string]*result
+	prev    *state
+	// The previous cycle in CYCLE_FILE, or nil.
+	prevCycle *cycleSnapshot
+}
+
+// A change since the last reminder in JSON.
+type changeReport struct {
+	Host   string `json:"host"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// Build a reminder of checks after the previous cycle in CYCLE_FILE,
+// which may be nil.
+// Changes are included if STATE_FILE has a previous state.
+func newReminder(config *config, now time.Time, targets []target,
+	checks []*hostCheck, exMap map[string]*result, prevCycle *cycleSnapshot) *reminder {
+	rem := &reminder{
+		Subject:   reminderSubject,
+		Time:      now,
+		Checker:   checkerName,
+		config:    config,
+		targets:   targets,
+		exMap:     exMap,
+		prevCycle: prevCycle,
+	}
+	for _, c := range checks {
+		if c.result == nil {
+			continue
+		}
+		report := newHostReport(config, c, now)
+		if report.Tier == tierOK.String() {
+			rem.Others = append(rem.Others, report)
+		} else {
+			rem.Soon = append(rem.Soon, report)
+		}
+	}
+
+	if config.stateFile != "" {
+		prev, err := loadState(config.stateFile)
+		if err != nil {
+			log.Printf("ERROR loading state: %v", err)
+		}
+		rem.prev = prev
+	}
+	if rem.prev != nil {
+		for _, c := range diffState(config, now, targets, exMap, rem.prev, prevCycle) {
+			rem.Changes = append(rem.Changes, changeReport{c.host, c.kind, c.detail})
+		}
+		for _, t := range targets {
+			if r, ok := exMap[t.host]; ok && config.escalated(rem.prev, t.host, r, now) {
+				rem.Escalated = append(rem.Escalated, t.host)
+			}
+		}
+	}
+	return rem
+}
+
+// A reminder of a subset of hosts.
+func (rem *reminder) subset(hosts map[string]bool) *reminder {
+	sub := &reminder{
+		Subject:     rem.Subject,
+		Simulated:   rem.Simulated,
+		Time:        rem.Time,
+		Checker:     rem.Checker,
+		config:      rem.config,
+		exMap:       rem.exMap,
+		prev:        rem.prev,
+		prevCycle:   rem.prevCycle,
+		StaleAgents: rem.StaleAgents,
+		Bounces:     rem.Bounces,
+		// Not filtered by hosts, as they don't concern certificates.
+		LatencyWarnings: rem.LatencyWarnings,
+	}
+	for _, t := range rem.targets {
+		if hosts[t.host] {
+			sub.targets = append(sub.targets, t)
+		}
+	}
+	for _, h := range rem.Soon {
+		if hosts[h.Host] {
+			sub.Soon = append(sub.Soon, h)
+		}
+	}
+	for _, h := range rem.Others {
+		if hosts[h.Host] {
+			sub.Others = append(sub.Others, h)
+		}
+	}
+	for _, c := range rem.Changes {
+		if hosts[c.Host] {
+			sub.Changes = append(sub.Changes, c)
+		}
+	}
+	for _, c := range rem.CycleChanges {
+		if hosts[c.Host] {
+			sub.CycleChanges = append(sub.CycleChanges, c)
+		}
+	}
+	for _, host := range rem.Escalated {
+		if hosts[host] {
+			sub.Escalated = append(sub.Escalated, host)
+		}
+	}
+	for _, e := range rem.JustExpired {
+		if hosts[e.Host] {
+			sub.JustExpired = append(sub.JustExpired, e)
+		}
+	}
+	for _, d := range rem.Domains {
+		for _, host := range d.Hosts {
+			if hosts[host] {
+				sub.Domains = append(sub.Domains, d)
+				break
+			}
+		}
+	}
+	for _, p := range rem.LaggingPairs {
+		if hosts[p.Follower.Host] {
+			sub.LaggingPairs = append(sub.LaggingPairs, p)
+		}
+	}
+	return sub
+}
+
+// Read From addresses by groups like web=web@example.com,infra=ops@example.org.
+// Exit process if it's malformed.
+func readGroupFrom(config *config) map[string]string {
+	specs := splitOptional(envOptional("GROUP_FROM", ""))
+	if len(specs) == 0 {
+		return nil
+	}
+	if config.groupBy == "" {
+		log.Fatalf("GROUP_FROM requires GROUP_BY")
+	}
+	groupFrom := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			log.Fatalf("Invalid GROUP_FROM: %q", spec)
+		}
+		groupFrom[kv[0]] = kv[1]
+	}
+	return groupFrom
+}
+
+// Read a template file given by an environmental variable.
+// Returns nil if it's not set. Exit process if it's malformed.
+func readTemplate(key string) *template.Template {
+	path := envOptional(key, "")
+	if len(path) == 0 {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read %v: %v", key, err)
+	}
+	tmpl, err := template.New(key).Parse(string(data))
+	if err != nil {
+		log.Fatalf("Failed to parse %v: %v", key, err)
+	}
+	return tmpl
+}
+
+// Render a reminder with a template.
+func render(tmpl *template.Template, rem *reminder) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, rem); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Read configured notifiers.
+func readNotifiers(config *config, sgConfig *sendgridConfig) []notifier {
+	notifiers := []notifier{&emailNotifier{
+		config:          config,
+		sgConfig:        sgConfig,
+		emails:          config.emails,
+		from:            config.from,
+		groupFrom:       readGroupFrom(config),
+		categories:      splitOptional(envOptional("SENDGRID_CATEGORIES", "")),
+		noChangesNoMail: envOptionalBool("NO_CHANGES_NO_MAIL", false),
+		highPriority:    readHighPriorityTier(),
+		tmpl:            readTemplate("EMAIL_TEMPLATE_FILE"),
+	}}
+	if url := envOptional("SLACK_WEBHOOK_URL", ""); len(url) > 0 {
+		notifiers = append(notifiers, &slackNotifier{
+			url:  url,
+			tmpl: readTemplate("SLACK_TEMPLATE_FILE"),
+		})
+	}
+	if url := envOptional("WEBHOOK_URL", ""); len(url) > 0 {
+		notifiers = append(notifiers, &webhookNotifier{
+			url:  url,
+			tmpl: readTemplate("WEBHOOK_TEMPLATE_FILE"),
+		})
+	}
+	if github := readGitHubNotifier(); github != nil {
+		notifiers = append(notifiers, github)
+	}
+	if command := envOptional("EXEC_NOTIFIER", ""); len(command) > 0 {
+		notifiers = append(notifiers, &execNotifier{
+			command: command,
+			timeout: envOptionalDuration("NOTIFY_TIMEOUT", 30*time.Second),
+		})
+	}
+	if l := readLogNotifier(); l != nil {
+		notifiers = append(notifiers, l)
+	}
+	return notifiers
+}
+
+// Sends reminders via SendGrid.
+type emailNotifier struct {
+	config   *config
+	sgConfig *sendgridConfig
+	emails   []string
+	from     string
+	// From addresses by groups of GROUP_BY, overriding from.
+	groupFrom map[string]string
+	// SendGrid categories of mails.
+	categories []string
+	// Skip mails without changes since the previous cycle.
+	noChangesNoMail bool
+	// Mails of hosts in this tier or above are marked high priority.
+	// tierOK for none.
+	highPriority tier
+	tmpl         *template.Template
+}
+
+// Read MAIL_HIGH_PRIORITY_TIER. Exit process if it's unknown.
+func readHighPriorityTier() tier {
+	value := envOptional("MAIL_HIGH_PRIORITY_TIER", "expired")
+	if value == "none" {
+		return tierOK
+	}
+	var t tier
+	if err := t.UnmarshalText([]byte(value)); err != nil || t == tierOK {
+		log.Fatalf("Unknown MAIL_HIGH_PRIORITY_TIER %q", value)
+	}
+	return t
+}
+
+// The highest tier of hosts in a reminder.
+func (rem *reminder) highestTier() tier {
+	highest := tierOK
+	for _, h := range rem.Soon {
+		var t tier
+		if t.UnmarshalText([]byte(h.Tier)) == nil && t > highest {
+			highest = t
+		}
+	}
+	return highest
+}
+
+func (e *emailNotifier) name() string {
+	return "email"
+}
+
+func (e *emailNotifier) recipients() string {
+	recipients := strings.Join(e.emails, ", ")
+	if len(e.config.escalationEmails) > 0 {
+		recipients += fmt.Sprintf(" (cc %v on escalation)",
+			strings.Join(e.config.escalationEmails, ", "))
+	}
+	if len(e.config.justExpiredEmails) > 0 {
+		recipients += fmt.Sprintf(" (cc %v on expiration)",
+			strings.Join(e.config.justExpiredEmails, ", "))
+	}
+	return recipients
+}
+
+// Send a mail per From address if groups have their own ones, and per
+// recipients of owners of certificates by OWNER_FIELD.
+// Otherwise send a mail of all hosts.
+// Hosts having emails tags are also mailed to those addresses.
+func (e *emailNotifier) notify(rem *reminder) error {
+	if e.noChangesNoMail && rem.prevCycle != nil && len(rem.CycleChanges) == 0 {
+		log.Println("Not mailing without changes since last check")
+		return errNotifySkipped
+	}
+	if len(e.groupFrom) == 0 && e.config.owners == nil {
+		if err := e.send(rem, e.from, e.emails); err != nil {
+			return err
+		}
+		return e.sendHostEmails(rem)
+	}
+
+	type mail struct {
+		from  string
+		to    []string
+		owned bool
+		hosts map[string]bool
+	}
+	mails := make(map[string]*mail)
+	var keys []string
+	for _, t := range rem.targets {
+		from, ok := e.groupFrom[t.tags[rem.config.groupBy]]
+		if !ok {
+			from = e.from
+		}
+		to := e.config.owners.recipients(rem.exMap[t.host])
+		owned := to != nil
+		if !owned {
+			to = e.emails
+		}
+		key := fmt.Sprintf("%v %v %v", from, strings.Join(to, ","), owned)
+		if mails[key] == nil {
+			mails[key] = &mail{from, to, owned, make(map[string]bool)}
+			keys = append(keys, key)
+		}
+		mails[key].hosts[t.host] = true
+	}
+	for _, key := range keys {
+		m := mails[key]
+		sub := rem.subset(m.hosts)
+		// Owners are mailed only of their hosts needing attention.
+		if m.owned && len(sub.Soon) == 0 {
+			continue
+		}
+		if err := e.send(sub, m.from, m.to); err != nil {
+			return err
+		}
+	}
+	return e.sendHostEmails(rem)
+}
+
+// Send a mail per emails tag like a@example.com|b@example.com of hosts
+// to the addresses, of the hosts having it which need attention.
+func (e *emailNotifier) sendHostEmails(rem *reminder) error {
+	hostsByEmails := make(map[string]map[string]bool)
+	var emails []string
+	for _, t := range rem.targets {
+		value := t.tags["emails"]
+		if value == "" {
+			continue
+		}
+		if hostsByEmails[value] == nil {
+			hostsByEmails[value] = make(map[string]bool)
+			emails = append(emails, value)
+		}
+		hostsByEmails[value][t.host] = true
+	}
+	for _, value := range emails {
+		sub := rem.subset(hostsByEmails[value])
+		if len(sub.Soon) == 0 {
+			continue
+		}
+		if err := e.send(sub, e.from, strings.Split(value, "|")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Send a mail of a reminder from an address to addresses.
+func (e *emailNotifier) send(rem *reminder, from string, to []string) error {
+	body := ""
+	if e.tmpl != nil {
+		var err error
+		if body, err = render(e.tmpl, rem); err != nil {
+			return err
+		}
+	} else {
+		body = reminderText(rem)
+	}
+	if rem.Simulated {
+		body = simulationWatermark + "\n\n" + body
+	}
+
+	sg := sendgrid.NewSendGridClient(e.sgConfig.username, e.sgConfig.password)
+	msg := sendgrid.NewMail()
+	msg.AddTos(to)
+	if len(rem.Escalated) > 0 {
+		for _, email := range rem.config.escalationEmails {
+			msg.AddCc(email)
+		}
+	}
+	if len(rem.JustExpired) > 0 {
+		for _, email := range rem.config.justExpiredEmails {
+			msg.AddCc(email)
+		}
+	}
+	if e.highPriority != tierOK && rem.highestTier() >= e.highPriority {
+		msg.AddHeader("X-Priority", "1 (Highest)")
+		msg.AddHeader("X-MSMail-Priority", "High")
+		msg.AddHeader("Importance", "high")
+	}
+	msg.SetSubject(rem.Subject)
+	msg.SetText(body)
+	msg.SetFrom(from)
+	if len(e.categories) > 0 {
+		msg.SetCategories(e.categories)
+	}
+	if err := sg.Send(msg); err != nil {
+		return fmt.Errorf("sending mail from %v to %v: %v", from, to, err)
+	}
+	log.Printf("Mail sent from %v to %v", from, to)
+	return nil
+}
+
+// Check the SendGrid credentials by getting the profile of the account.
+func (e *emailNotifier) ping() error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.PostForm("https://"+sendgridHost+"/api/profile.get.json", url.Values{
+		"api_user": {e.sgConfig.username},
+		"api_key":  {e.sgConfig.password},
+	})
+	if err != nil {
+		return fmt.Errorf("getting SendGrid profile: %v", err)
+	}
+	defer resp.Body.Close()
+	var failure struct {
+		Error interface{} `json:"error"`
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if json.Unmarshal(body, &failure) == nil && failure.Error != nil {
+		return fmt.Errorf("getting SendGrid profile: %v", failure.Error)
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("getting SendGrid profile: unexpected status %v", resp.Status)
+	}
+	return nil
+}
+
+// Post JSON to a URL, expecting a 2xx response.
+func postJSON(url string, body []byte) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return nil
+}
+
+// Sends reminders to a Slack incoming webhook.
+type slackNotifier struct {
+	url  string
+	tmpl *template.Template
+}
+
+func (s *slackNotifier) name() string {
+	return "slack"
+}
+
+func (s *slackNotifier) recipients() string {
+	return "the Slack webhook"
+}
+
+// The built-in Slack format, listing hosts expiring soon.
+func slackText(rem *reminder) string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("*%v*\n", rem.Subject))
+	for _, c := range rem.Changes {
+		buf.WriteString(fmt.Sprintf("• %v: %v (%v)\n", c.Host, c.Kind, c.Detail))
+	}
+	for _, h := range rem.Soon {
+		host := fmt.Sprintf("`%v`", h.Host)
+		if h.URL != "" {
+			host = fmt.Sprintf("<%v|%v>", h.URL, h.Host)
+		}
+		buf.WriteString(fmt.Sprintf("• %v %v, %.0f days remaining\n",
+			host, h.Tier, *h.DaysRemaining))
+	}
+	if rem.Checker != "" {
+		buf.WriteString(fmt.Sprintf("_Checked by %v_\n", rem.Checker))
+	}
+	return buf.String()
+}
+
+func (s *slackNotifier) notify(rem *reminder) error {
+	text := ""
+	if s.tmpl != nil {
+		var err error
+		if text, err = render(s.tmpl, rem); err != nil {
+			return err
+		}
+	} else {
+		text = slackText(rem)
+	}
+	if rem.Simulated && s.tmpl != nil {
+		text = simulationWatermark + "\n" + text
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	if err := postJSON(s.url, body); err != nil {
+		return fmt.Errorf("posting to Slack: %v", err)
+	}
+	log.Println("Posted to Slack")
+	return nil
+}
+
+// Check the Slack webhook by posting an empty message, which Slack
+// rejects with 400 if the webhook is valid and 403, 404 or 410 if not.
+func (s *slackNotifier) ping() error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(s.url, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		return fmt.Errorf("posting to Slack: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("posting to Slack: unexpected status %v", resp.Status)
+	}
+	return nil
+}
+
+// Posts reminders to a URL, in JSON unless a template is given.
+type webhookNotifier struct {
+	url  string
+	tmpl *template.Template
+}
+
+func (wh *webhookNotifier) name() string {
+	return "webhook"
+}
+
+func (wh *webhookNotifier) recipients() string {
+	return wh.url
+}
+
+func (wh *webhookNotifier) notify(rem *reminder) error {
+	var body []byte
+	if wh.tmpl != nil {
+		text, err := render(wh.tmpl, rem)
+		if err != nil {
+			return err
+		}
+		body = []byte(text)
+	} else {
+		var err error
+		if body, err = json.Marshal(rem); err != nil {
+			return err
+		}
+	}
+	if err := postJSON(wh.url, body); err != nil {
+		return fmt.Errorf("posting to %v: %v", wh.url, err)
+	}
+	log.Printf("Posted to %v", wh.url)
+	return nil
+}
+
+// Post {"ping": true}, which must be answered with 2xx.
+func (wh *webhookNotifier) ping() error {
+	if err := postJSON(wh.url, []byte(`{"ping": true}`)); err != nil {
+		return fmt.Errorf("posting to %v: %v", wh.url, err)
+	}
+	return nil
+}
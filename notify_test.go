@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSkippedMailIsNotSent(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	notAfter := now.AddDate(0, 0, 5)
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	prev := &state{RemindedAt: now.AddDate(0, 0, -1), Hosts: map[string]hostState{
+		"www.example.com": {NotAfter: notAfter, Tier: tierWarning, Reminded: 1,
+			UpdatedAt: now.AddDate(0, 0, -1)},
+	}}
+	if err := saveState(stateFile, prev); err != nil {
+		t.Fatal(err)
+	}
+	before, err := ioutil.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &config{
+		thresholdDays: 30,
+		criticalDays:  1,
+		stateFile:     stateFile,
+		dedupWindows:  map[string]time.Duration{"email": time.Hour},
+	}
+	rem := &reminder{
+		Time:      now,
+		Soon:      []hostReport{{Host: "www.example.com", Tier: tierWarning.String()}},
+		config:    config,
+		targets:   []target{{host: "www.example.com"}},
+		exMap:     map[string]*result{"www.example.com": {notAfter: notAfter}},
+		prev:      prev,
+		prevCycle: &cycleSnapshot{FinishedAt: now.Add(-time.Hour)},
+	}
+	email := &emailNotifier{config: config, noChangesNoMail: true}
+	if err := email.notify(rem); err != errNotifySkipped {
+		t.Fatalf("notify without changes: got %v, want errNotifySkipped", err)
+	}
+
+	status := newCycleStatus(now)
+	remind(config, []notifier{email}, status, rem)
+
+	after, err := ioutil.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("state saved after a skipped mail:\n%s", after)
+	}
+	if counts := status.notifications["email"]; len(counts) != 0 {
+		t.Errorf("skipped mail counted as a notification: %v", counts)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The version of the schema of exit summaries.
+// Incremented on incompatible changes.
+const summarySchemaVersion = 1
+
+// Exit reasons of the once command, with their exit codes.
+const (
+	exitOK        = "ok"
+	exitAttention = "attention"
+	exitFailed    = "failed"
+	// Some hosts failed with FAIL_ON_UNREACHABLE.
+	exitUnreachable = "unreachable"
+)
+
+var exitCodes = map[string]int{exitOK: 0, exitAttention: 1, exitFailed: 2,
+	exitUnreachable: 3}
+
+// A summary of the once command written to SUMMARY_FILE.
+type exitSummary struct {
+	SchemaVersion int    `json:"schema_version"`
+	Version       string `json:"version"`
+	ExitReason    string `json:"exit_reason"`
+	ExitCode      int    `json:"exit_code"`
+	// Counts of hosts by tiers, and "error" for hosts failed.
+	Counts map[string]int `json:"counts"`
+	Hosts  []hostReport   `json:"hosts"`
+}
+
+// Summarize checks of a cycle.
+// Any host failing is unreachable rather than attention if
+// failOnUnreachable, even if it's muted.
+func newExitSummary(reports []hostReport, failed, failOnUnreachable bool) *exitSummary {
+	summary := &exitSummary{
+		SchemaVersion: summarySchemaVersion,
+		Version:       version,
+		ExitReason:    exitOK,
+		Counts:        make(map[string]int),
+		Hosts:         reports,
+	}
+	unreachable := false
+	for _, report := range reports {
+		if report.Error != "" {
+			summary.Counts["error"]++
+			// Failures of new hosts are expected while they're set up.
+			if report.GraceUntil != nil {
+				continue
+			}
+			unreachable = true
+		} else {
+			summary.Counts[report.Tier]++
+		}
+		if report.MutedUntil == nil && (report.Error != "" ||
+			report.Tier != tierOK.String() || len(report.Findings) > 0) {
+			summary.ExitReason = exitAttention
+		}
+	}
+	if unreachable && failOnUnreachable {
+		summary.ExitReason = exitUnreachable
+	}
+	if failed {
+		summary.ExitReason = exitFailed
+	}
+	summary.ExitCode = exitCodes[summary.ExitReason]
+	return summary
+}
+
+// Open SUMMARY_FILE, a path or a file descriptor like fd:3.
+func openSummaryFile(path string) (*os.File, error) {
+	if value := strings.TrimPrefix(path, "fd:"); value != path {
+		fd, err := strconv.Atoi(value)
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("Invalid file descriptor %q", path)
+		}
+		return os.NewFile(uintptr(fd), path), nil
+	}
+	return os.Create(path)
+}
+
+// Parse a time of --as-of like 2024-12-31 or 2024-12-31T09:00:00+09:00.
+// Dates are at midnight in TIMEZONE.
+func parseAsOf(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// Check hosts once like "sslreminder once", remind if necessary and exit.
+// It exits with 0 if no hosts need attention, 1 if some do and 2 if all
+// hosts failed, or 3 if some hosts failed with FAIL_ON_UNREACHABLE,
+// writing a JSON summary to SUMMARY_FILE if it's set.
+// --dry-run prints reminders instead of sending them, writing no files,
+// --as-of evaluates reminders as if now were the given time in a dry run,
+// and --simulate sends reminders of simulated expirations without writing
+// files.
+func onceCommand(args []string) {
+	flags := flag.NewFlagSet("once", flag.ExitOnError)
+	asOf := flags.String("as-of", "", "evaluate as if now were this date or RFC 3339 time")
+	dryRun := flags.Bool("dry-run", false, "print reminders instead of sending them")
+	simulate := flags.String("simulate", "", "simulate expirations like shop.example.com=3d, overriding SIMULATE_EXPIRY")
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		log.Fatalf("Usage: sslreminder once [--as-of <time>] [--dry-run] [--simulate <host>=<duration>,...]")
+	}
+	config := readConfig()
+	if *simulate != "" {
+		var err error
+		if config.simulated, err = parseSimulatedExpiry(*simulate); err != nil {
+			log.Fatalf("Invalid --simulate: %v", err)
+		}
+	}
+	notifiers := readNotifiers(config, readSendgridConfig())
+	now := time.Now()
+	if *asOf != "" {
+		var err error
+		if now, err = parseAsOf(*asOf, config.location); err != nil {
+			log.Fatalf("Invalid --as-of %q", *asOf)
+		}
+		// Never send or persist a simulated future.
+		log.Printf("Evaluating as of %v without sending reminders", now)
+		*dryRun = true
+	}
+	if *dryRun {
+		config.dryRun = true
+		for i, n := range notifiers {
+			notifiers[i] = dryRunNotifier{n}
+		}
+	}
+	status := newCycleStatus(time.Now())
+	check(config, notifiers, status, &cycleRequest{id: 1}, now)
+
+	status.mu.Lock()
+	failed := status.failed
+	status.mu.Unlock()
+	summary := newExitSummary(status.hostReports(config, now), failed,
+		envOptionalBool("FAIL_ON_UNREACHABLE", false))
+	log.Printf("Exiting with %v: %v", summary.ExitCode, summary.ExitReason)
+
+	if path := envOptional("SUMMARY_FILE", ""); len(path) > 0 {
+		f, err := openSummaryFile(path)
+		if err != nil {
+			log.Fatalf("Failed to open SUMMARY_FILE: %v", err)
+		}
+		if err := json.NewEncoder(f).Encode(summary); err != nil {
+			log.Fatalf("Failed to write SUMMARY_FILE: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("Failed to write SUMMARY_FILE: %v", err)
+		}
+	}
+	os.Exit(summary.ExitCode)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"log"
+	"strings"
+	"unicode"
+)
+
+// Routes reminders of hosts to recipients by the owning team encoded in
+// their certificates.
+type ownerRouting struct {
+	// ou, o, or an OID of a subject attribute or an extension.
+	field string
+	// Recipients by owners.
+	emails map[string][]string
+}
+
+// Read OWNER_FIELD and OWNER_EMAILS like
+// "platform=a@example.com|b@example.com,payments=c@example.com".
+// Nil if OWNER_FIELD is not set. Exit process if they're malformed.
+func readOwnerRouting() *ownerRouting {
+	field := envOptional("OWNER_FIELD", "")
+	if field == "" {
+		return nil
+	}
+	if field != "ou" && field != "o" {
+		if !validOID(field) {
+			log.Fatalf("Invalid OWNER_FIELD %q, expected ou, o or an OID like 1.3.6.1.4.1.99999.1", field)
+		}
+	}
+	routing := &ownerRouting{field, make(map[string][]string)}
+	for _, spec := range splitOptional(envMandatory("OWNER_EMAILS")) {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			log.Fatalf("Invalid OWNER_EMAILS: %q", spec)
+		}
+		routing.emails[kv[0]] = strings.Split(kv[1], "|")
+	}
+	return routing
+}
+
+// Whether s is an OID like 1.3.6.1.4.1.99999.1.
+func validOID(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// The owner of a certificate by the field, or "" if it has none.
+// An extension is read as an ASN.1 string, or as is if it's printable.
+func (o *ownerRouting) owner(cert *x509.Certificate) string {
+	switch o.field {
+	case "ou":
+		if len(cert.Subject.OrganizationalUnit) > 0 {
+			return cert.Subject.OrganizationalUnit[0]
+		}
+		return ""
+	case "o":
+		if len(cert.Subject.Organization) > 0 {
+			return cert.Subject.Organization[0]
+		}
+		return ""
+	}
+	for _, name := range cert.Subject.Names {
+		if name.Type.String() == o.field {
+			if value, ok := name.Value.(string); ok {
+				return value
+			}
+		}
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.String() != o.field {
+			continue
+		}
+		var value string
+		if _, err := asn1.Unmarshal(ext.Value, &value); err == nil {
+			return value
+		}
+		if printable(string(ext.Value)) {
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+func printable(s string) bool {
+	for _, c := range s {
+		if !unicode.IsPrint(c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// The recipients of a host serving r by its owner, or nil if the
+// certificate has no owner or the owner isn't in OWNER_EMAILS.
+// r may be nil if the host has no result.
+func (o *ownerRouting) recipients(r *result) []string {
+	if o == nil || r == nil || len(r.chain) == 0 {
+		return nil
+	}
+	return o.emails[o.owner(r.chain[0])]
+}
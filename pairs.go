@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// A pair of hosts where the follower is renewed after the leader,
+// like staging and production.
+type hostPair struct {
+	leader   string
+	follower string
+}
+
+// Read PAIRS like staging.example.com->www.example.com.
+// Exit process if it's malformed.
+func readHostPairs() []hostPair {
+	var pairs []hostPair
+	for _, spec := range splitOptional(envOptional("PAIRS", "")) {
+		hosts := strings.SplitN(spec, "->", 2)
+		if len(hosts) != 2 {
+			log.Fatalf("Invalid PAIRS: %q", spec)
+		}
+		leader, follower := strings.TrimSpace(hosts[0]), strings.TrimSpace(hosts[1])
+		if leader == "" || follower == "" || leader == follower {
+			log.Fatalf("Invalid PAIRS: %q", spec)
+		}
+		pairs = append(pairs, hostPair{leader, follower})
+	}
+	return pairs
+}
+
+// A certificate of a host in a pair in JSON.
+type pairedCert struct {
+	Host     string    `json:"host"`
+	Serial   string    `json:"serial"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// A pair whose follower hasn't been renewed within PAIR_LAG after
+// its leader.
+type pairReport struct {
+	Leader   pairedCert `json:"leader"`
+	Follower pairedCert `json:"follower"`
+}
+
+func newPairedCert(host string, r *result) pairedCert {
+	return pairedCert{host, r.chain[0].SerialNumber.String(), r.notAfter}
+}
+
+// Pairs whose leaders were renewed more than PAIR_LAG ago while their
+// followers still serve older certificates.
+// Pairs without results of both hosts are skipped.
+func (config *config) laggingPairs(exMap map[string]*result, now time.Time) []pairReport {
+	var reports []pairReport
+	for _, p := range config.pairs {
+		leader, ok := exMap[p.leader]
+		if !ok || len(leader.chain) == 0 {
+			continue
+		}
+		follower, ok := exMap[p.follower]
+		if !ok || len(follower.chain) == 0 {
+			continue
+		}
+		renewed := leader.chain[0].SerialNumber.Cmp(follower.chain[0].SerialNumber) != 0 &&
+			leader.notBefore.After(follower.notBefore)
+		if renewed && now.Sub(leader.notBefore) > config.pairLag {
+			reports = append(reports, pairReport{
+				newPairedCert(p.leader, leader),
+				newPairedCert(p.follower, follower),
+			})
+		}
+	}
+	return reports
+}
+
+// Text of lagging pairs of a reminder.
+func laggingPairsText(rem *reminder) string {
+	if len(rem.LaggingPairs) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Following hosts haven't been renewed within %v after their leaders:\n",
+		rem.config.pairLag)
+	for _, p := range rem.LaggingPairs {
+		fmt.Fprintf(&buf, "%v (serial %v, expires %v)\n  renewed before %v (serial %v, expires %v)\n",
+			p.Follower.Host, p.Follower.Serial, p.Follower.NotAfter,
+			p.Leader.Host, p.Leader.Serial, p.Leader.NotAfter)
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Negotiate TLS over a connection by the preamble of a protocol before
+// the handshake, if the protocol has one like mysql and postgres.
+func startTLS(conn net.Conn, protocol string) error {
+	switch protocol {
+	case "mysql":
+		return mysqlPreamble(conn)
+	case "postgres":
+		return postgresPreamble(conn)
+	default:
+		return nil
+	}
+}
+
+// The code of SSLRequest of the PostgreSQL protocol.
+const postgresSSLRequestCode = 80877103
+
+// Send SSLRequest of the PostgreSQL protocol, expecting S for accepted.
+func postgresPreamble(conn net.Conn) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+	response := make([]byte, 1)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return fmt.Errorf("Failed to read response to SSLRequest: %v", err)
+	}
+	if response[0] != 'S' {
+		return fmt.Errorf("PostgreSQL server doesn't accept SSL (%q)", response[0])
+	}
+	return nil
+}
+
+// Capability flags of the MySQL protocol.
+const (
+	mysqlClientProtocol41       = 0x00000200
+	mysqlClientSSL              = 0x00000800
+	mysqlClientSecureConnection = 0x00008000
+)
+
+// Read the initial handshake packet of a MySQL server and send
+// SSLRequest if the server supports SSL.
+func mysqlPreamble(conn net.Conn) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("Failed to read MySQL handshake: %v", err)
+	}
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return fmt.Errorf("Failed to read MySQL handshake: %v", err)
+	}
+	if len(payload) > 3 && payload[0] == 0xff {
+		return fmt.Errorf("MySQL server refused the connection: %s", payload[3:])
+	}
+
+	// Protocol version, NUL terminated server version, connection id,
+	// auth plugin data and a filler precede lower capability flags.
+	i := 1
+	for i < len(payload) && payload[i] != 0 {
+		i++
+	}
+	i += 1 + 4 + 8 + 1
+	if i+2 > len(payload) {
+		return fmt.Errorf("Malformed MySQL handshake")
+	}
+	if binary.LittleEndian.Uint16(payload[i:i+2])&mysqlClientSSL == 0 {
+		return fmt.Errorf("MySQL server doesn't support SSL")
+	}
+
+	request := make([]byte, 4+32)
+	request[0] = 32
+	request[3] = header[3] + 1
+	binary.LittleEndian.PutUint32(request[4:8],
+		mysqlClientProtocol41|mysqlClientSSL|mysqlClientSecureConnection)
+	binary.LittleEndian.PutUint32(request[8:12], 1<<24)
+	// utf8mb4_general_ci, followed by reserved zeros.
+	request[12] = 45
+	_, err := conn.Write(request)
+	return err
+}
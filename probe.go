@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timeouts of GET /probe.
+const (
+	defaultProbeTimeout = 10 * time.Second
+	maxProbeTimeout     = 60 * time.Second
+)
+
+// Coalesces concurrent probes of the same target into one check.
+type probeGroup struct {
+	mu    sync.Mutex
+	calls map[string]*probeCall
+}
+
+// A probe in flight.
+type probeCall struct {
+	done  chan struct{}
+	check *hostCheck
+}
+
+func newProbeGroup() *probeGroup {
+	return &probeGroup{calls: make(map[string]*probeCall)}
+}
+
+// Check a target, or wait for the check of the same target in flight.
+func (g *probeGroup) do(t target, timeout time.Duration) *hostCheck {
+	g.mu.Lock()
+	if call, ok := g.calls[t.host]; ok {
+		g.mu.Unlock()
+		<-call.done
+		return call.check
+	}
+	call := &probeCall{done: make(chan struct{})}
+	g.calls[t.host] = call
+	g.mu.Unlock()
+
+	call.check = checkTargets([]target{t}, timeout)[0]
+
+	g.mu.Lock()
+	delete(g.calls, t.host)
+	g.mu.Unlock()
+	close(call.done)
+	return call.check
+}
+
+// Get the monitored target of a host, or nil if it's not monitored.
+func (s *cycleStatus) monitored(host string) *target {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.hosts[host]; ok {
+		t := c.target
+		return &t
+	}
+	return nil
+}
+
+// Replace the latest check of a monitored host with a probe.
+// Hosts not monitored are left as is.
+func (s *cycleStatus) refresh(c *hostCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.hosts[c.target.host]; ok {
+		s.hosts[c.target.host] = c
+	}
+}
+
+// Whether a host not monitored can be probed.
+func (config *config) probeAllowed(host string) bool {
+	if config.allowArbitraryProbes {
+		return true
+	}
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	for _, allowed := range config.probeAllowlist {
+		if host == allowed || name == allowed ||
+			strings.HasPrefix(allowed, ".") && strings.HasSuffix(name, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Write a probe in the Prometheus text format.
+func writeProbeMetrics(w http.ResponseWriter, c *hostCheck, now time.Time) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	success := 0
+	if c.err == nil {
+		success = 1
+	}
+	fmt.Fprintln(w, "# HELP probe_success Whether the probe succeeded.")
+	fmt.Fprintln(w, "# TYPE probe_success gauge")
+	fmt.Fprintf(w, "probe_success %v\n", success)
+	fmt.Fprintln(w, "# HELP probe_duration_seconds Duration of the probe.")
+	fmt.Fprintln(w, "# TYPE probe_duration_seconds gauge")
+	fmt.Fprintf(w, "probe_duration_seconds %v\n", c.duration.Seconds())
+	if r := c.result; r != nil {
+		fmt.Fprintln(w, "# HELP ssl_cert_not_after_timestamp_seconds Expiration of the certificate.")
+		fmt.Fprintln(w, "# TYPE ssl_cert_not_after_timestamp_seconds gauge")
+		fmt.Fprintf(w, "ssl_cert_not_after_timestamp_seconds %v\n", r.notAfter.Unix())
+		fmt.Fprintln(w, "# HELP ssl_cert_days_remaining Days until the certificate expires.")
+		fmt.Fprintln(w, "# TYPE ssl_cert_days_remaining gauge")
+		fmt.Fprintf(w, "ssl_cert_days_remaining %.2f\n", secondsBetween(now, r.notAfter)/(24*60*60))
+	}
+}
+
+// Check a target on demand like GET /probe?target=host:port&timeout=5s.
+// Returns JSON if it's accepted, or metrics in the Prometheus text format.
+func probeHandler(config *config, status *cycleStatus) http.HandlerFunc {
+	probes := newProbeGroup()
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.URL.Query().Get("target")
+		if host == "" {
+			http.Error(w, "target is required", http.StatusBadRequest)
+			return
+		}
+		if err := validateHost(host); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		timeout := defaultProbeTimeout
+		if value := r.URL.Query().Get("timeout"); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				http.Error(w, "invalid timeout", http.StatusBadRequest)
+				return
+			}
+			if d < maxProbeTimeout {
+				timeout = d
+			} else {
+				timeout = maxProbeTimeout
+			}
+		}
+
+		t := status.monitored(host)
+		if t == nil {
+			if !config.probeAllowed(host) {
+				http.Error(w, "target is not allowed", http.StatusForbidden)
+				return
+			}
+			t = &target{host: host}
+		}
+
+		c := probes.do(*t, timeout)
+		status.refresh(c)
+
+		now := time.Now()
+		if !strings.Contains(r.Header.Get("Accept"), "application/json") {
+			writeProbeMetrics(w, c, now)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(newHostReport(config, c, now)); err != nil {
+			log.Printf("ERROR writing probe: %v", err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Default ports of implicit TLS protocols.
+var protocolPorts = map[string]string{
+	"https": "443",
+	"imaps": "993",
+	"pop3s": "995",
+	"ftps":  "990",
+	// Negotiated by their preambles before handshakes.
+	"mysql":    "3306",
+	"postgres": "5432",
+}
+
+// Apply the protocol of a target given by a scheme like imaps://host or
+// a protocol tag, giving the host its default port unless it has one.
+func (t *target) applyProtocol() error {
+	if i := strings.Index(t.host, "://"); i >= 0 {
+		if t.tags == nil {
+			t.tags = make(map[string]string)
+		}
+		t.tags["protocol"] = t.host[:i]
+		t.host = t.host[i+3:]
+	}
+	protocol, ok := t.tags["protocol"]
+	if !ok {
+		return nil
+	}
+	port, ok := protocolPorts[protocol]
+	if !ok {
+		return fmt.Errorf("Unknown protocol %q of %v", protocol, t.host)
+	}
+	if _, _, err := net.SplitHostPort(t.host); err != nil {
+		t.host = net.JoinHostPort(t.host, port)
+	}
+	return nil
+}
+
+// The protocol of a target, https unless it's tagged.
+func (t target) protocol() string {
+	if protocol, ok := t.tags["protocol"]; ok {
+		return protocol
+	}
+	return "https"
+}
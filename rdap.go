@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Statuses of domain registrations.
+const (
+	domainOK          = "ok"
+	domainExpiring    = "expiring"
+	domainExpired     = "expired"
+	domainUnsupported = "unsupported"
+	domainError       = "error"
+)
+
+// Looks up expirations of registrations of domains of hosts via RDAP.
+type rdapChecker struct {
+	// The base URL to look up domains of TLDs not in baseURLs,
+	// like https://rdap.org/ redirecting to registries.
+	bootstrapURL string
+	// Base URLs of RDAP servers by TLDs.
+	baseURLs      map[string]string
+	thresholdDays int
+	cacheTTL      time.Duration
+	client        *http.Client
+
+	mu    sync.Mutex
+	cache map[string]rdapCacheEntry
+}
+
+type rdapCacheEntry struct {
+	expiration *time.Time
+	// The registry has no expiration of the domain, which is cached
+	// for the lifetime of the process.
+	unsupported bool
+	fetchedAt   time.Time
+}
+
+// A registration of a domain in JSON.
+type domainReport struct {
+	Domain     string     `json:"domain"`
+	Status     string     `json:"status"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	// Hosts under the domain.
+	Hosts []string `json:"hosts"`
+}
+
+// A domain in RDAP JSON.
+type rdapDomain struct {
+	Events []struct {
+		Action string    `json:"eventAction"`
+		Date   time.Time `json:"eventDate"`
+	} `json:"events"`
+}
+
+// Read RDAP configs. Nil unless DOMAIN_CHECK is true.
+// Exit process if they're malformed.
+func readRDAPChecker() *rdapChecker {
+	if !envOptionalBool("DOMAIN_CHECK", false) {
+		return nil
+	}
+	baseURLs := make(map[string]string)
+	for _, spec := range splitOptional(envOptional("RDAP_BASE_URLS", "")) {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			log.Fatalf("Invalid RDAP_BASE_URLS: %q", spec)
+		}
+		baseURLs[strings.ToLower(kv[0])] = withSlash(kv[1])
+	}
+	cacheHours := envOptionalInt("RDAP_CACHE_HOURS", 24)
+	if cacheHours < 24 {
+		log.Fatalf("RDAP_CACHE_HOURS must be at least 24 to respect rate limits of registries")
+	}
+	return &rdapChecker{
+		bootstrapURL:  withSlash(envOptional("RDAP_BOOTSTRAP_URL", "https://rdap.org/")),
+		baseURLs:      baseURLs,
+		thresholdDays: envOptionalInt("DOMAIN_THRESHOLD_DAYS", 30),
+		cacheTTL:      time.Duration(cacheHours) * time.Hour,
+		client:        &http.Client{Timeout: 30 * time.Second},
+		cache:         make(map[string]rdapCacheEntry),
+	}
+}
+
+// A URL with a trailing slash.
+func withSlash(u string) string {
+	if strings.HasSuffix(u, "/") {
+		return u
+	}
+	return u + "/"
+}
+
+// The registered domain of a target, given by a domain tag for
+// suffixes like co.uk, or the last two labels of the host.
+// Empty for IP addresses.
+func (t target) registeredDomain() string {
+	if domain, ok := t.tags["domain"]; ok {
+		return strings.ToLower(domain)
+	}
+	host := t.host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(host, ".")), ".")
+	if len(labels) < 2 {
+		return ""
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
+
+// Registrations of domains of targets, sorted by domains.
+func (rc *rdapChecker) check(targets []target, now time.Time) []domainReport {
+	hostsByDomain := make(map[string][]string)
+	for _, t := range targets {
+		if domain := t.registeredDomain(); domain != "" {
+			hostsByDomain[domain] = append(hostsByDomain[domain], t.host)
+		}
+	}
+	domains := make([]string, 0, len(hostsByDomain))
+	for domain := range hostsByDomain {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	reports := make([]domainReport, 0, len(domains))
+	for _, domain := range domains {
+		report := domainReport{Domain: domain, Hosts: hostsByDomain[domain]}
+		entry, err := rc.lookup(domain)
+		switch {
+		case err != nil:
+			report.Status = domainError
+			report.Error = err.Error()
+		case entry.unsupported:
+			report.Status = domainUnsupported
+		default:
+			report.Expiration = entry.expiration
+			report.Status = domainOK
+			if entry.expiration.Before(now) {
+				report.Status = domainExpired
+			} else if entry.expiration.Before(now.AddDate(0, 0, rc.thresholdDays)) {
+				report.Status = domainExpiring
+			}
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// Look up a domain, from the cache if it's fresh.
+// A stale cache is used when the lookup fails.
+func (rc *rdapChecker) lookup(domain string) (rdapCacheEntry, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	cached, ok := rc.cache[domain]
+	if ok && (cached.unsupported || time.Since(cached.fetchedAt) < rc.cacheTTL) {
+		return cached, nil
+	}
+	entry, err := rc.query(domain)
+	if err != nil {
+		if ok {
+			log.Printf("ERROR looking up %v via RDAP, using cache: %v", domain, err)
+			return cached, nil
+		}
+		return entry, err
+	}
+	if entry.unsupported {
+		log.Printf("Registration of %v is unsupported by RDAP", domain)
+	} else {
+		log.Printf("Registration of %v expires at %v", domain, entry.expiration)
+	}
+	rc.cache[domain] = entry
+	return entry, nil
+}
+
+// Query RDAP for the expiration of a domain.
+func (rc *rdapChecker) query(domain string) (rdapCacheEntry, error) {
+	entry := rdapCacheEntry{fetchedAt: time.Now()}
+	baseURL := rc.bootstrapURL
+	if u, ok := rc.baseURLs[domain[strings.LastIndex(domain, ".")+1:]]; ok {
+		baseURL = u
+	}
+	req, err := http.NewRequest(http.MethodGet, baseURL+"domain/"+domain, nil)
+	if err != nil {
+		return entry, err
+	}
+	req.Header.Set("Accept", "application/rdap+json")
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return entry, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		entry.unsupported = true
+		return entry, nil
+	}
+	if resp.StatusCode/100 != 2 {
+		return entry, fmt.Errorf("Unexpected status %v", resp.Status)
+	}
+	var d rdapDomain
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return entry, fmt.Errorf("Failed to parse RDAP response: %v", err)
+	}
+	for _, e := range d.Events {
+		if e.Action == "expiration" {
+			expiration := e.Date
+			entry.expiration = &expiration
+			return entry, nil
+		}
+	}
+	entry.unsupported = true
+	return entry, nil
+}
+
+// Domains needing attention, expiring, expired or failing lookups.
+func domainsNeedingAttention(reports []domainReport) []domainReport {
+	var attention []domainReport
+	for _, d := range reports {
+		if d.Status != domainOK && d.Status != domainUnsupported {
+			attention = append(attention, d)
+		}
+	}
+	return attention
+}
+
+// Text of the domain registrations section of a reminder.
+func domainsText(rem *reminder) string {
+	if len(rem.Domains) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	buf.WriteString("Domain registrations:\n")
+	for _, d := range rem.Domains {
+		if d.Error != "" {
+			fmt.Fprintf(&buf, "%v: %v (%v)\n", d.Domain, d.Status, d.Error)
+			continue
+		}
+		fmt.Fprintf(&buf, "%v: %v at %v\n", d.Domain, d.Status, d.Expiration)
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+// The version of sslreminder, set by -ldflags "-X main.version=...".
+var version = "dev"
+
+// A report of a host in JSON.
+type hostReport struct {
+	Host          string            `json:"host"`
+	SNI           string            `json:"sni,omitempty"`
+	Source        string            `json:"source,omitempty"`
+	Protocol      string            `json:"protocol"`
+	Tags          map[string]string `json:"tags,omitempty"`
+	CheckedAt     time.Time         `json:"checked_at"`
+	DurationSecs  float64           `json:"duration_seconds"`
+	NotAfter      *time.Time        `json:"not_after,omitempty"`
+	DaysRemaining *float64          `json:"days_remaining,omitempty"`
+	// Percentage of the validity period remaining.
+	RemainingPercent *float64 `json:"remaining_percent,omitempty"`
+	Tier             string   `json:"tier,omitempty"`
+	// The threshold applied to the host and why, like "issuer DigiCert".
+	ThresholdDays   int     `json:"threshold_days,omitempty"`
+	ThresholdSource string  `json:"threshold_source,omitempty"`
+	Issuer          string  `json:"issuer,omitempty"`
+	Verification    string  `json:"verification,omitempty"`
+	ChainDepth      int     `json:"chain_depth,omitempty"`
+	TLSVersion      string  `json:"tls_version,omitempty"`
+	CipherSuite     string  `json:"cipher_suite,omitempty"`
+	HandshakeSecs   float64 `json:"handshake_seconds,omitempty"`
+	// Outcomes of handshakes forced to DOWNGRADE_VERSIONS by versions.
+	Downgrades map[string]string `json:"downgrades,omitempty"`
+	Findings   map[string]string `json:"findings,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	ErrorClass string            `json:"error_class,omitempty"`
+	// Typical days remaining at renewals learned from HISTORY_FILE.
+	TypicalRenewalDays *float64 `json:"typical_renewal_days,omitempty"`
+	// Ratio of successful checks within RELIABILITY_WINDOW.
+	SuccessRate *float64 `json:"success_rate,omitempty"`
+	// The interval and the next check of the host if ADAPTIVE_CHECKS.
+	CheckInterval string     `json:"check_interval,omitempty"`
+	NextCheck     *time.Time `json:"next_check,omitempty"`
+	// The end of the mute window if the host is muted.
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+	// The end of NEW_HOST_GRACE if the host is new.
+	GraceUntil *time.Time `json:"grace_until,omitempty"`
+	// The owner in the certificate by OWNER_FIELD.
+	Owner string `json:"owner,omitempty"`
+	// The address checked and its host if CHECK_ALL_IPS.
+	IP           string `json:"ip,omitempty"`
+	ResolvedFrom string `json:"resolved_from,omitempty"`
+	// The details page if DETAIL_LINKS is enabled.
+	URL string `json:"url,omitempty"`
+}
+
+// Build a report of a check.
+func newHostReport(config *config, c *hostCheck, now time.Time) hostReport {
+	report := hostReport{
+		Host:               c.target.host,
+		SNI:                c.target.sni(),
+		IP:                 c.target.ip,
+		ResolvedFrom:       c.target.resolvedFrom,
+		Source:             c.target.source,
+		Protocol:           c.target.protocol(),
+		Tags:               c.target.tags,
+		CheckedAt:          c.checkedAt,
+		DurationSecs:       c.duration.Seconds(),
+		URL:                config.detailURL(c.target.host),
+		MutedUntil:         config.mutedUntil(c.target, now),
+		GraceUntil:         config.graceUntil(c.target, now),
+		TypicalRenewalDays: config.history.typicalRenewal(c.target.host),
+		SuccessRate:        config.history.successRate(c.target.host, now),
+	}
+	if c.err != nil {
+		report.Error = c.err.Error()
+		report.ErrorClass = errorClass(c.err)
+		if report.ErrorClass == errorCertificate {
+			report.Verification = "failed: " + report.Error
+		}
+		return report
+	}
+	r := c.result
+	days := secondsBetween(now, r.notAfter) / (24 * 60 * 60)
+	report.NotAfter = &r.notAfter
+	report.DaysRemaining = &days
+	report.RemainingPercent = remainingPercent(r, now)
+	report.Tier = config.tierOf(c.target.host, r, now).String()
+	report.ThresholdDays, report.ThresholdSource = config.thresholdOf(c.target.host, r)
+	if len(r.chain) > 0 {
+		report.Issuer = r.chain[0].Issuer.String()
+	}
+	report.ChainDepth = len(r.chain)
+	report.Verification = r.verification
+	report.Downgrades = r.downgrades
+	if config.owners != nil && len(r.chain) > 0 {
+		report.Owner = config.owners.owner(r.chain[0])
+	}
+	report.Findings = hostFindings(config, c.target, r, now)
+	if next := config.nextCheck(c); next != nil {
+		report.CheckInterval = config.adaptiveInterval(r, c.checkedAt).String()
+		report.NextCheck = next
+	}
+	if r.tlsVersion != 0 {
+		report.TLSVersion = tls.VersionName(r.tlsVersion)
+		report.CipherSuite = tls.CipherSuiteName(r.cipherSuite)
+	}
+	report.HandshakeSecs = r.handshake.Seconds()
+	return report
+}
+
+// Reports of the latest checks, sorted by hosts.
+func (s *cycleStatus) hostReports(config *config, now time.Time) []hostReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reports := make([]hostReport, 0, len(s.hosts))
+	for _, host := range sortedHosts(s.hosts) {
+		reports = append(reports, newHostReport(config, s.hosts[host], now))
+	}
+	return reports
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Check a target, retrying up to RESET_RETRIES times while its
+// connection is reset, which flaky load balancers do intermittently
+// mid-handshake. Other errors aren't retried. The last reset is
+// reported if retries are exhausted.
+func (config *config) checkRetryingResets(t target) *hostCheck {
+	c := checkTargets([]target{t}, config.timeout)[0]
+	for retry := 1; c.err != nil && errorClass(c.err) == errorReset; retry++ {
+		if retry > config.resetRetries {
+			if config.resetRetries > 0 {
+				log.Printf("ERROR connection to %v reset after %v retries", t.host, config.resetRetries)
+			}
+			break
+		}
+		log.Printf("Connection to %v reset, retrying in %v (%v of %v)",
+			t.host, config.resetRetryDelay, retry, config.resetRetries)
+		time.Sleep(config.resetRetryDelay)
+		c = checkTargets([]target{t}, config.timeout)[0]
+	}
+	return c
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Checks reachability of OCSP responders and CRL distribution points,
+// caching results of URLs shared by certificates within a cycle.
+type revocationChecker struct {
+	client *http.Client
+	// Guards errors, as hosts are checked concurrently.
+	mu     sync.Mutex
+	errors map[string]error
+}
+
+func newRevocationChecker(timeout time.Duration) *revocationChecker {
+	return &revocationChecker{
+		client: &http.Client{Timeout: timeout},
+		errors: make(map[string]error),
+	}
+}
+
+// Whether a URL responds to HTTP, in any status.
+func (rc *revocationChecker) reach(url string) error {
+	rc.mu.Lock()
+	err, ok := rc.errors[url]
+	rc.mu.Unlock()
+	if ok {
+		return err
+	}
+	resp, err := rc.client.Get(url)
+	if err == nil {
+		resp.Body.Close()
+	}
+	rc.mu.Lock()
+	rc.errors[url] = err
+	rc.mu.Unlock()
+	return err
+}
+
+// Unreachable revocation endpoints of a certificate like
+// "OCSP http://ocsp.example.com: <error>".
+func (rc *revocationChecker) unreachable(cert *x509.Certificate) []string {
+	var endpoints []string
+	for _, url := range cert.OCSPServer {
+		if err := rc.reach(url); err != nil {
+			endpoints = append(endpoints, fmt.Sprintf("OCSP %v: %v", url, err))
+		}
+	}
+	for _, url := range cert.CRLDistributionPoints {
+		if err := rc.reach(url); err != nil {
+			endpoints = append(endpoints, fmt.Sprintf("CRL %v: %v", url, err))
+		}
+	}
+	return endpoints
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// The version of the schema of run summaries posted to SUMMARY_WEBHOOK.
+// Incremented on incompatible changes.
+const runSummarySchemaVersion = 1
+
+// The number of hosts closest to expiry in run summaries without TOP_N.
+const defaultSummaryTopN = 5
+
+// A compact summary of a cycle posted to SUMMARY_WEBHOOK.
+type runSummary struct {
+	SchemaVersion int    `json:"schema_version"`
+	Version       string `json:"version"`
+	Cycle         int    `json:"cycle"`
+	// The name of the checker by CHECKER_NAME.
+	Checker string    `json:"checker,omitempty"`
+	Time    time.Time `json:"time"`
+	// Hosts in the cycle, and those checked rather than carried.
+	Hosts   int `json:"hosts"`
+	Checked int `json:"checked"`
+	// Counts of hosts by tiers, and "error" for hosts failed.
+	Counts map[string]int `json:"counts"`
+	// Hosts closest to expiry, soonest first.
+	Top      []summaryHost    `json:"top"`
+	Failures []summaryFailure `json:"failures"`
+}
+
+// A host closest to expiry in a run summary.
+type summaryHost struct {
+	Host          string    `json:"host"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysRemaining float64   `json:"days_remaining"`
+	Tier          string    `json:"tier"`
+}
+
+// A host failed in a run summary.
+type summaryFailure struct {
+	Host       string `json:"host"`
+	Error      string `json:"error"`
+	ErrorClass string `json:"error_class"`
+}
+
+// Summarize a cycle.
+func newRunSummary(config *config, res *cycleResult) *runSummary {
+	checks := append(append([]*hostCheck(nil), res.checks...), res.carried...)
+	summary := &runSummary{
+		SchemaVersion: runSummarySchemaVersion,
+		Version:       version,
+		Cycle:         res.id,
+		Checker:       checkerName,
+		Time:          res.now,
+		Hosts:         len(checks),
+		Checked:       len(res.checks),
+		Counts:        make(map[string]int),
+		Top:           []summaryHost{},
+		Failures:      []summaryFailure{},
+	}
+	reports := make([]hostReport, 0, len(checks))
+	for _, c := range checks {
+		report := newHostReport(config, c, res.now)
+		reports = append(reports, report)
+		if report.Error != "" {
+			summary.Counts["error"]++
+			summary.Failures = append(summary.Failures,
+				summaryFailure{report.Host, report.Error, report.ErrorClass})
+		} else {
+			summary.Counts[report.Tier]++
+		}
+	}
+	n := config.topN
+	if n <= 0 {
+		n = defaultSummaryTopN
+	}
+	for _, r := range topExpiring(reports, n) {
+		summary.Top = append(summary.Top,
+			summaryHost{r.Host, *r.NotAfter, *r.DaysRemaining, r.Tier})
+	}
+	return summary
+}
+
+// Posts a summary of every cycle to SUMMARY_WEBHOOK, whether or not
+// it reminds.
+type summaryWebhookSink struct {
+	config *config
+	url    string
+}
+
+func (s *summaryWebhookSink) name() string {
+	return "SUMMARY_WEBHOOK"
+}
+
+func (s *summaryWebhookSink) publish(res *cycleResult) error {
+	body, err := json.Marshal(newRunSummary(s.config, res))
+	if err != nil {
+		return err
+	}
+	if err := postJSON(s.url, body); err != nil {
+		return fmt.Errorf("posting to %v: %v", s.url, err)
+	}
+	log.Printf("Posted summary of cycle %v to %v", res.id, s.url)
+	return nil
+}
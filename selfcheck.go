@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// The SendGrid API reached by reminder mails.
+const sendgridHost = "api.sendgrid.com"
+
+// Check outbound connectivity to CANARY_HOST and SendGrid, so that
+// a broken network is told from monitored hosts being down.
+// Only logs errors, and is skipped if SELF_CHECK is false.
+func selfCheck(timeout time.Duration) {
+	if !envOptionalBool("SELF_CHECK", true) {
+		return
+	}
+	hosts := []string{envOptional("CANARY_HOST", "www.google.com"), sendgridHost}
+	for _, host := range hosts {
+		if _, err := GetResult(target{host: host}, timeout); err != nil {
+			log.Printf("ERROR self-check can't reach %v (%v): %v. "+
+				"Outbound network may be broken.", host, errorClass(err), err)
+			continue
+		}
+		log.Printf("Self-check reached %v", host)
+	}
+}
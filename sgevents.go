@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Events of SendGrid meaning mails didn't reach recipients.
+var undeliveredEvents = map[string]bool{
+	"bounce":  true,
+	"dropped": true,
+	"blocked": true,
+}
+
+// An event posted by the SendGrid event webhook.
+type sendgridEvent struct {
+	Email     string `json:"email"`
+	Event     string `json:"event"`
+	Reason    string `json:"reason"`
+	Timestamp int64  `json:"timestamp"`
+	// A string or an array of strings.
+	Category json.RawMessage `json:"category"`
+}
+
+// Categories of an event.
+func (e sendgridEvent) categories() []string {
+	var categories []string
+	if json.Unmarshal(e.Category, &categories) == nil {
+		return categories
+	}
+	var category string
+	if json.Unmarshal(e.Category, &category) == nil && category != "" {
+		return []string{category}
+	}
+	return nil
+}
+
+// A recipient a reminder didn't reach, in JSON.
+type bounceReport struct {
+	Email  string    `json:"email"`
+	Event  string    `json:"event"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// Record an undelivered mail, replacing an older one of the recipient.
+func (s *cycleStatus) recordBounce(b bounceReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bounces[b.Email] = b
+	s.bounceCounts[b.Event]++
+}
+
+// Recipients reminders didn't reach since the last call, sorted by
+// addresses.
+func (s *cycleStatus) takeBounces() []bounceReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bounces := make([]bounceReport, 0, len(s.bounces))
+	for _, b := range s.bounces {
+		bounces = append(bounces, b)
+	}
+	sort.Slice(bounces, func(i, j int) bool { return bounces[i].Email < bounces[j].Email })
+	s.bounces = make(map[string]bounceReport)
+	return bounces
+}
+
+// Text of bounces of a reminder.
+func bouncesText(rem *reminder) string {
+	if len(rem.Bounces) == 0 {
+		return ""
+	}
+	var buf bytes.Buffer
+	buf.WriteString("Previous reminders didn't reach following recipients:\n")
+	for _, b := range rem.Bounces {
+		fmt.Fprintf(&buf, "%v: %v at %v %v\n", b.Email, b.Event, b.At, b.Reason)
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+// Receive events of the SendGrid event webhook like
+// POST /sendgrid/events?token=..., where token is SENDGRID_WEBHOOK_TOKEN.
+// Bounces of mails in SENDGRID_CATEGORIES, or of any mails without them,
+// are logged and listed in the next reminder.
+func sendgridEventsHandler(config *config, status *cycleStatus) http.HandlerFunc {
+	categories := splitOptional(envOptional("SENDGRID_CATEGORIES", ""))
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config.sendgridWebhookToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		token := r.URL.Query().Get("token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(config.sendgridWebhookToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		var events []sendgridEvent
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 10<<20)).Decode(&events); err != nil {
+			http.Error(w, fmt.Sprintf("malformed JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		for _, e := range events {
+			if !undeliveredEvents[e.Event] {
+				continue
+			}
+			if len(categories) > 0 && !anyContained(categories, e.categories()) {
+				continue
+			}
+			log.Printf("ERROR reminder to %v: %v %v", e.Email, e.Event, e.Reason)
+			status.recordBounce(bounceReport{e.Email, e.Event, e.Reason, time.Unix(e.Timestamp, 0)})
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Whether any of values is in list.
+func anyContained(list, values []string) bool {
+	for _, v := range values {
+		if contains(list, v) {
+			return true
+		}
+	}
+	return false
+}
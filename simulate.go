@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The watermark of notifications of simulated expirations.
+const simulationWatermark = "TEST — simulated data"
+
+// Parse simulated expirations like shop.example.com=3d,api.example.com=12h
+// into durations from now by hosts.
+func parseSimulatedExpiry(value string) (map[string]time.Duration, error) {
+	simulated := make(map[string]time.Duration)
+	for _, spec := range splitOptional(value) {
+		kv := strings.SplitN(spec, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("Invalid simulated expiry %q", spec)
+		}
+		d, err := parseDays(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid simulated expiry %q: %v", spec, err)
+		}
+		simulated[kv[0]] = d
+	}
+	return simulated, nil
+}
+
+// Parse a duration like 3d or 12h.
+func parseDays(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// Read SIMULATE_EXPIRY. Exit process if it's malformed.
+func readSimulatedExpiry() map[string]time.Duration {
+	simulated, err := parseSimulatedExpiry(envOptional("SIMULATE_EXPIRY", ""))
+	if err != nil {
+		log.Fatalf("Failed to parse SIMULATE_EXPIRY: %v", err)
+	}
+	return simulated
+}
+
+// Whether results are written to files like STATE_FILE and HISTORY_FILE,
+// which they aren't in dry runs or simulations.
+func (config *config) persists() bool {
+	return !config.dryRun && len(config.simulated) == 0
+}
+
+// Checks with expirations of simulated hosts replaced by now plus their
+// durations. Checks are copied rather than modified.
+func (config *config) simulate(checks []*hostCheck, now time.Time) []*hostCheck {
+	if len(config.simulated) == 0 {
+		return checks
+	}
+	simulated := make([]*hostCheck, len(checks))
+	for i, c := range checks {
+		d, ok := config.simulated[c.target.host]
+		if !ok || c.result == nil {
+			simulated[i] = c
+			continue
+		}
+		r := *c.result
+		r.notAfter = now.Add(d)
+		copied := *c
+		copied.result = &r
+		simulated[i] = &copied
+		log.Printf("Simulating expiration of %v at %v", c.target.host, r.notAfter)
+	}
+	return simulated
+}
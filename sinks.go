@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Checks of a cycle published to sinks.
+type cycleResult struct {
+	id int
+	// Checks of hosts checked in the cycle.
+	checks []*hostCheck
+	// The last checks of hosts skipped in the cycle.
+	carried []*hostCheck
+	now     time.Time
+}
+
+// A sink is an output updated with results of each cycle.
+// Each sink must update its output as a whole or not at all.
+type sink interface {
+	name() string
+	publish(res *cycleResult) error
+}
+
+// Configured sinks.
+// Files are not written nor StatsD is pushed to in dry runs or simulations.
+func resultSinks(config *config, status *cycleStatus) []sink {
+	sinks := []sink{status}
+	if !config.persists() {
+		return sinks
+	}
+	if config.history != nil {
+		sinks = append(sinks, config.history)
+	}
+	if config.cycleFile != "" {
+		sinks = append(sinks, &cycleFileSink{config, config.cycleFile})
+	}
+	if config.archive != nil {
+		sinks = append(sinks, config.archive)
+	}
+	if config.statsdAddr != "" {
+		sinks = append(sinks, &statsdSink{config.statsdAddr})
+	}
+	if config.summaryWebhook != "" {
+		sinks = append(sinks, &summaryWebhookSink{config, config.summaryWebhook})
+	}
+	return sinks
+}
+
+// Publish results of a cycle to sinks.
+// A failure of a sink is logged without affecting others.
+func publishResults(sinks []sink, res *cycleResult) {
+	for _, s := range sinks {
+		if err := s.publish(res); err != nil {
+			log.Printf("ERROR publishing cycle %v to %v: %v", res.id, s.name(), err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// A sink recording results published to it, failing with err if it's set.
+type fakeSink struct {
+	sinkName  string
+	err       error
+	published []*cycleResult
+}
+
+func (s *fakeSink) name() string {
+	return s.sinkName
+}
+
+func (s *fakeSink) publish(res *cycleResult) error {
+	s.published = append(s.published, res)
+	return s.err
+}
+
+func testCycleResult(now time.Time) *cycleResult {
+	notAfter := now.AddDate(0, 0, 60)
+	return &cycleResult{
+		id:  1,
+		now: now,
+		checks: []*hostCheck{
+			{target: target{host: "www.example.com"},
+				result: &result{notBefore: now, notAfter: notAfter}, checkedAt: now},
+			{target: target{host: "down.example.com"},
+				err: errors.New("connection refused"), checkedAt: now},
+		},
+		carried: []*hostCheck{
+			{target: target{host: "api.example.com"},
+				result: &result{notBefore: now, notAfter: notAfter}, checkedAt: now.Add(-time.Hour)},
+		},
+	}
+}
+
+func TestPublishResultsToEverySink(t *testing.T) {
+	res := testCycleResult(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	sinks := []*fakeSink{
+		{sinkName: "first"},
+		{sinkName: "failing", err: errors.New("disk full")},
+		{sinkName: "last"},
+	}
+	var all []sink
+	for _, s := range sinks {
+		all = append(all, s)
+	}
+	publishResults(all, res)
+	publishResults(all, res)
+	for _, s := range sinks {
+		if len(s.published) != 2 || s.published[0] != res || s.published[1] != res {
+			t.Errorf("%v received %v, want the same results twice", s.sinkName, s.published)
+		}
+	}
+}
+
+func TestResultSinks(t *testing.T) {
+	status := newCycleStatus(time.Now())
+	config := &config{
+		history:        newTestHistory("history.jsonl"),
+		cycleFile:      "cycle.json",
+		statsdAddr:     "localhost:8125",
+		summaryWebhook: "https://hooks.example.com/summary",
+	}
+	var names []string
+	for _, s := range resultSinks(config, status) {
+		names = append(names, s.name())
+	}
+	want := []string{"status", "history", "cycle file", "StatsD", "SUMMARY_WEBHOOK"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+
+	config.dryRun = true
+	if sinks := resultSinks(config, status); len(sinks) != 1 || sinks[0] != sink(status) {
+		t.Errorf("dry run: got %v sinks, want only status", len(sinks))
+	}
+}
+
+func TestSinksReceiveSameResults(t *testing.T) {
+	// HISTORY_FILE is compacted as of the current time.
+	now := time.Now().Truncate(time.Second)
+	dir := t.TempDir()
+	status := newCycleStatus(now)
+	history := newTestHistory(filepath.Join(dir, "history.jsonl"))
+	cycleFile := filepath.Join(dir, "cycle.json")
+	failing := &fakeSink{sinkName: "failing", err: errors.New("unreachable")}
+	recorder := &fakeSink{sinkName: "recorder"}
+	config := &config{thresholdDays: 30, criticalDays: 7}
+	sinks := []sink{status, failing, history, &cycleFileSink{config, cycleFile}, recorder}
+
+	res := testCycleResult(now)
+	publishResults(sinks, res)
+
+	// Hosts checked in the cycle, and carried ones for snapshots.
+	for _, host := range []string{"www.example.com", "down.example.com", "api.example.com"} {
+		if status.lastCheck(host) == nil {
+			t.Errorf("status doesn't have %v", host)
+		}
+	}
+	records, err := loadHistoryRecords(history.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Host != "www.example.com" ||
+		records[1].Host != "down.example.com" || records[1].Error == "" {
+		t.Errorf("history got %+v, want checks of the cycle", records)
+	}
+	snapshot, err := loadCycleSnapshot(cycleFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Hosts) != 3 || snapshot.Hosts["down.example.com"].Error == "" ||
+		!snapshot.FinishedAt.Equal(now) {
+		t.Errorf("cycle file got %+v, want all checks", snapshot)
+	}
+	if len(failing.published) != 1 || len(recorder.published) != 1 ||
+		recorder.published[0] != res {
+		t.Errorf("sinks after a failing one didn't receive the results")
+	}
+}
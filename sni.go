@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The server name sent via SNI, given by a sni tag like
+// gw.example.com:8443;sni=api.example.com so that each host:port can
+// have its own. Empty to send the name of the host.
+func (t target) sni() string {
+	return t.tags["sni"]
+}
+
+// Validate the sni tag of a target if any.
+func (t target) validateSNI() error {
+	sni, ok := t.tags["sni"]
+	if ok && (sni == "" || strings.ContainsAny(sni, ":/ ")) {
+		return fmt.Errorf("Invalid sni %q of %v", sni, t.host)
+	}
+	return nil
+}
+
+// The name of a target in reports, the server name if it's configured
+// followed by the host:port connected to, in their Unicode forms.
+func (t target) displayName() string {
+	if sni := t.sni(); sni != "" {
+		return fmt.Sprintf("%v (via %v)", unicodeHost(sni), unicodeHost(t.host))
+	}
+	return unicodeHost(t.host)
+}
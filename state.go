@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A severity of a certificate by its remaining days.
+type tier int
+
+const (
+	tierOK tier = iota
+	tierWarning
+	tierCritical
+	tierExpired
+)
+
+func (t tier) String() string {
+	switch t {
+	case tierWarning:
+		return "warning"
+	case tierCritical:
+		return "critical"
+	case tierExpired:
+		return "expired"
+	default:
+		return "ok"
+	}
+}
+
+func (t tier) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *tier) UnmarshalText(text []byte) error {
+	for _, candidate := range []tier{tierOK, tierWarning, tierCritical, tierExpired} {
+		if candidate.String() == string(text) {
+			*t = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown tier %q", text)
+}
+
+// Get the tier of an expiration date.
+// Warning within the threshold of the host in effect and critical within
+// CRITICAL_DAYS.
+func (config *config) tierOf(host string, r *result, now time.Time) tier {
+	switch {
+	case r.notAfter.Before(now):
+		return tierExpired
+	case config.within(r.notAfter, now, config.criticalDays):
+		return tierCritical
+	case config.within(r.notAfter, now, config.effectiveThreshold(host, r)):
+		return tierWarning
+	default:
+		return tierOK
+	}
+}
+
+// The version of the schema of STATE_FILE.
+// Version 1 had neither schema_version nor updated_at.
+const stateSchemaVersion = 2
+
+// State persisted in STATE_FILE between runs.
+type state struct {
+	SchemaVersion int `json:"schema_version"`
+	// When the last reminder was sent.
+	RemindedAt time.Time            `json:"reminded_at"`
+	Hosts      map[string]hostState `json:"hosts"`
+}
+
+// State of a host at the last reminder.
+type hostState struct {
+	NotAfter time.Time `json:"not_after"`
+	Tier     tier      `json:"tier"`
+	// Consecutive reminders since the host needed attention,
+	// until it's acknowledged.
+	Reminded int `json:"reminded,omitempty"`
+	// Acknowledged by the ack command, until the certificate changes.
+	Acked bool `json:"acked,omitempty"`
+	// When the record was last updated, to merge states.
+	UpdatedAt time.Time `json:"updated_at"`
+	// The last notifications by notifiers, for NOTIFY_DEDUP.
+	Notified map[string]notification `json:"notified,omitempty"`
+}
+
+// Migrate state of an older schema version to the current one.
+// Fails if it's of a newer version than this sslreminder supports.
+func (s *state) migrate() error {
+	if s.SchemaVersion > stateSchemaVersion {
+		return fmt.Errorf("State of schema version %v is newer than %v supported by this sslreminder; upgrade sslreminder",
+			s.SchemaVersion, stateSchemaVersion)
+	}
+	if s.SchemaVersion < 2 {
+		for host, h := range s.Hosts {
+			h.UpdatedAt = s.RemindedAt
+			s.Hosts[host] = h
+		}
+	}
+	s.SchemaVersion = stateSchemaVersion
+	return nil
+}
+
+// Merge other state into s, keeping the most recently updated record
+// of each host.
+func (s *state) merge(other *state) {
+	if other.RemindedAt.After(s.RemindedAt) {
+		s.RemindedAt = other.RemindedAt
+	}
+	for host, h := range other.Hosts {
+		if mine, ok := s.Hosts[host]; !ok || h.UpdatedAt.After(mine.UpdatedAt) {
+			s.Hosts[host] = h
+		}
+	}
+}
+
+// Read state from a file.
+// Returns nil without error if the file doesn't exist yet.
+func loadState(path string) (*state, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", path, err)
+	}
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("Failed to load %v: %v", path, err)
+	}
+	return &s, nil
+}
+
+// Write state to a file atomically.
+func saveState(path string, s *state) error {
+	s.SchemaVersion = stateSchemaVersion
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// Write a file atomically by renaming a temporary file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Build state from results, counting reminders of hosts
+// needing attention since prev.
+// Targets without results keep their previous state, so that a failed
+// check doesn't forget acknowledgements and reminders, and so do targets
+// whose changes of expirations aren't confirmed since prevCycle yet.
+func newState(config *config, now time.Time, targets []target,
+	exMap map[string]*result, prev *state, prevCycle *cycleSnapshot) *state {
+	exMap = config.confirmedResults(prevCycle, exMap)
+	s := &state{
+		SchemaVersion: stateSchemaVersion,
+		RemindedAt:    now,
+		Hosts:         make(map[string]hostState),
+	}
+	for host, r := range exMap {
+		h := hostState{
+			NotAfter:  r.notAfter,
+			Tier:      config.tierOf(host, r, now),
+			UpdatedAt: now,
+		}
+		if h.Tier != tierOK {
+			h.Reminded = 1
+			if prev != nil {
+				if before, ok := prev.Hosts[host]; ok && before.NotAfter.Equal(r.notAfter) {
+					h.Acked = before.Acked
+					if !h.Acked {
+						h.Reminded = before.Reminded + 1
+					}
+				}
+			}
+			if h.Acked {
+				h.Reminded = 0
+			}
+		}
+		s.Hosts[host] = h
+	}
+	if prev != nil {
+		for _, t := range targets {
+			if _, ok := exMap[t.host]; ok {
+				continue
+			}
+			if before, ok := prev.Hosts[t.host]; ok {
+				s.Hosts[t.host] = before
+			}
+		}
+	}
+	return s
+}
+
+// Kinds of changes since the last reminder.
+const (
+	// Entered the reminder window, or newly checked within it.
+	changeNewlyExpiring = "newly expiring"
+	// Served a certificate expiring later than before.
+	changeRenewed = "renewed"
+	// Moved between warning, critical and expired.
+	changeTier = "tier changed"
+)
+
+// A change of a host since the last reminder.
+type change struct {
+	host   string
+	kind   string
+	detail string
+}
+
+// Changes of results since the previous state, in the order of targets.
+// Changes of expirations not confirmed since prevCycle are left out.
+func diffState(config *config, now time.Time, targets []target,
+	exMap map[string]*result, prev *state, prevCycle *cycleSnapshot) []change {
+	exMap = config.confirmedResults(prevCycle, exMap)
+	var changes []change
+	for _, t := range targets {
+		r, ok := exMap[t.host]
+		if !ok {
+			continue
+		}
+		current := config.tierOf(t.host, r, now)
+		before, ok := prev.Hosts[t.host]
+		switch {
+		case ok && r.notAfter.After(before.NotAfter):
+			changes = append(changes, change{t.host, changeRenewed,
+				fmt.Sprintf("%v -> %v", before.NotAfter, r.notAfter)})
+		case !ok || before.Tier == tierOK:
+			if current != tierOK {
+				changes = append(changes, change{t.host,
+					changeNewlyExpiring, current.String()})
+			}
+		case current != before.Tier:
+			changes = append(changes, change{t.host, changeTier,
+				fmt.Sprintf("%v -> %v", before.Tier, current)})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewStateKeepsErroredHosts(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	notAfter := now.AddDate(0, 0, 5)
+	prev := &state{Hosts: map[string]hostState{
+		"acked.example.com": {NotAfter: notAfter, Tier: tierWarning, Acked: true,
+			UpdatedAt: now.AddDate(0, 0, -1)},
+		"reminded.example.com": {NotAfter: notAfter, Tier: tierWarning, Reminded: 3,
+			UpdatedAt: now.AddDate(0, 0, -1)},
+		"removed.example.com": {NotAfter: notAfter, Tier: tierWarning, Reminded: 1},
+	}}
+	targets := []target{
+		{host: "acked.example.com"},
+		{host: "reminded.example.com"},
+		{host: "new.example.com"},
+	}
+	config := &config{thresholdDays: 30, criticalDays: 1}
+	s := newState(config, now, targets, map[string]*result{}, prev, nil)
+
+	if h := s.Hosts["acked.example.com"]; !h.Acked || !h.NotAfter.Equal(notAfter) {
+		t.Errorf("acknowledgement of an errored host not kept: %+v", h)
+	}
+	if h := s.Hosts["reminded.example.com"]; h.Reminded != 3 {
+		t.Errorf("reminders of an errored host: got %v, want 3", h.Reminded)
+	}
+	if _, ok := s.Hosts["new.example.com"]; ok {
+		t.Errorf("state of an errored host never checked before")
+	}
+	if _, ok := s.Hosts["removed.example.com"]; ok {
+		t.Errorf("state of a host no longer monitored kept")
+	}
+}
+
+func TestNewStateCountsReminders(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	notAfter := now.AddDate(0, 0, 5)
+	prev := &state{Hosts: map[string]hostState{
+		"www.example.com": {NotAfter: notAfter, Tier: tierWarning, Reminded: 2},
+	}}
+	targets := []target{{host: "www.example.com"}}
+	exMap := map[string]*result{"www.example.com": {notAfter: notAfter}}
+	config := &config{thresholdDays: 30, criticalDays: 1}
+	s := newState(config, now, targets, exMap, prev, nil)
+	if h := s.Hosts["www.example.com"]; h.Reminded != 3 || !h.UpdatedAt.Equal(now) {
+		t.Errorf("got %+v, want 3 reminders updated now", h)
+	}
+}
+
+func TestRenewalConfirmationsInState(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	before := now.AddDate(0, 0, 5)
+	renewed := now.AddDate(0, 0, 90)
+	prev := &state{Hosts: map[string]hostState{
+		"www.example.com": {NotAfter: before, Tier: tierWarning, Acked: true},
+	}}
+	targets := []target{{host: "www.example.com"}}
+	exMap := map[string]*result{"www.example.com": {notAfter: renewed}}
+	config := &config{thresholdDays: 30, criticalDays: 1, renewalConfirmations: 2}
+
+	// Observed for the first time.
+	prevCycle := &cycleSnapshot{Hosts: map[string]hostSnapshot{
+		"www.example.com": {NotAfter: &before, Tier: tierWarning},
+	}}
+	if changes := diffState(config, now, targets, exMap, prev, prevCycle); len(changes) != 0 {
+		t.Errorf("unconfirmed renewal in changes: %v", changes)
+	}
+	s := newState(config, now, targets, exMap, prev, prevCycle)
+	if h := s.Hosts["www.example.com"]; !h.NotAfter.Equal(before) || !h.Acked {
+		t.Errorf("unconfirmed renewal in state: %+v", h)
+	}
+
+	// Observed for the second time in a row.
+	prevCycle.Hosts["www.example.com"] = hostSnapshot{NotAfter: &before,
+		Tier: tierWarning, Unconfirmed: &renewed, Observed: 1}
+	changes := diffState(config, now, targets, exMap, prev, prevCycle)
+	if len(changes) != 1 || changes[0].kind != changeRenewed {
+		t.Errorf("confirmed renewal not in changes: %v", changes)
+	}
+	s = newState(config, now, targets, exMap, prev, prevCycle)
+	if h := s.Hosts["www.example.com"]; !h.NotAfter.Equal(renewed) || h.Acked {
+		t.Errorf("confirmed renewal not in state: %+v", h)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+// Pushes gauges of each cycle to StatsD in the DogStatsD format,
+// tagged by hosts.
+type statsdSink struct {
+	addr string
+}
+
+func (s *statsdSink) name() string {
+	return "StatsD"
+}
+
+// Send ssl.cert.days_left and ssl.check.success of all hosts over UDP,
+// a datagram per host. Hosts failing checks have no days_left.
+func (s *statsdSink) publish(res *cycleResult) error {
+	conn, err := net.Dial("udp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for _, c := range append(append([]*hostCheck(nil), res.checks...), res.carried...) {
+		var buf bytes.Buffer
+		tags := fmt.Sprintf("#host:%v", c.target.host)
+		if checkerName != "" {
+			tags += ",checker:" + checkerName
+		}
+		success := 0
+		if c.err == nil {
+			success = 1
+			buf.WriteString(fmt.Sprintf("ssl.cert.days_left:%.2f|g|%v\n",
+				c.result.notAfter.Sub(res.now).Hours()/24, tags))
+		}
+		buf.WriteString(fmt.Sprintf("ssl.check.success:%v|g|%v", success, tags))
+		if _, err := conn.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Status of check cycles, shared with the HTTP server.
+type cycleStatus struct {
+	mu sync.Mutex
+	// When the process started.
+	booted time.Time
+	// When the last cycle started and finished.
+	// Zero until the first cycle starts or finishes.
+	started  time.Time
+	finished time.Time
+	// Ids of the last started and finished cycles.
+	startedID  int
+	finishedID int
+	// Every host failed in the last finished cycle.
+	failed bool
+	// Any cycle has finished without failing.
+	succeeded bool
+	// When the next cycle is scheduled.
+	next time.Time
+
+	// The latest checks by hosts, of hosts checked in the last cycle.
+	hosts map[string]*hostCheck
+	// The number of finished cycles.
+	cycles int
+	// Counts of errors by hosts and error classes.
+	hostErrors map[string]map[string]int
+	// Counts of notifications by notifiers and whether they succeeded.
+	notifications map[string]map[bool]int
+	// Histograms of durations of checks and TLS handshakes.
+	durations  *histogram
+	handshakes *histogram
+	// Registrations of domains in the last cycle if DOMAIN_CHECK.
+	domains []domainReport
+	// Recipients reminders didn't reach since the last reminder, and
+	// counts of such SendGrid events.
+	bounces      map[string]bounceReport
+	bounceCounts map[string]int
+	// Events of cycles for GET /events.
+	events *eventHub
+	// When hosts were first seen, for NEW_HOST_GRACE.
+	seen map[string]time.Time
+}
+
+func newCycleStatus(now time.Time) *cycleStatus {
+	return &cycleStatus{
+		booted:        now,
+		hosts:         make(map[string]*hostCheck),
+		hostErrors:    make(map[string]map[string]int),
+		notifications: make(map[string]map[bool]int),
+		durations:     newHistogram(checkDurationBuckets),
+		handshakes:    newHistogram(checkDurationBuckets),
+		events:        newEventHub(),
+		bounces:       make(map[string]bounceReport),
+		bounceCounts:  make(map[string]int),
+		seen:          make(map[string]time.Time),
+	}
+}
+
+// Record checks of a cycle, and carried ones of hosts skipped in it.
+// Hosts not in the cycle are forgotten.
+func (s *cycleStatus) record(checks, carried []*hostCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hosts := make(map[string]*hostCheck, len(checks)+len(carried))
+	for _, c := range carried {
+		hosts[c.target.host] = c
+	}
+	for _, c := range checks {
+		host := c.target.host
+		hosts[host] = c
+		s.durations.observe(c.duration.Seconds())
+		if c.result != nil && c.result.handshake > 0 {
+			s.handshakes.observe(c.result.handshake.Seconds())
+		}
+		if c.err != nil {
+			if s.hostErrors[host] == nil {
+				s.hostErrors[host] = make(map[string]int)
+			}
+			s.hostErrors[host][errorClass(c.err)]++
+		}
+	}
+	for host := range s.hostErrors {
+		if _, ok := hosts[host]; !ok {
+			delete(s.hostErrors, host)
+		}
+	}
+	s.hosts = hosts
+}
+
+// Get the last check of a target if the target can be skipped now
+// by its check_days. Otherwise returns nil.
+// Targets critical, expired, failed or not checked yet are never skipped.
+func (s *cycleStatus) skippable(t target, config *config, now time.Time) *hostCheck {
+	days, err := t.checkDays()
+	if err != nil || days == nil || days[now.Weekday()] {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.hosts[t.host]
+	if !ok || prev.result == nil ||
+		config.tierOf(t.host, prev.result, now) >= tierCritical {
+		return nil
+	}
+	return &hostCheck{t, prev.result, nil, prev.checkedAt, prev.duration}
+}
+
+func (s *cycleStatus) name() string {
+	return "status"
+}
+
+// Publish results of a cycle to the status served via HTTP.
+func (s *cycleStatus) publish(res *cycleResult) error {
+	s.record(res.checks, res.carried)
+	return nil
+}
+
+// Get the last check of a target if it's not due by its adaptive
+// interval yet. Otherwise returns nil.
+func (s *cycleStatus) notDue(t target, config *config, now time.Time) *hostCheck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.hosts[t.host]
+	if !ok {
+		return nil
+	}
+	if next := config.nextCheck(prev); next != nil && now.Before(*next) {
+		return &hostCheck{t, prev.result, nil, prev.checkedAt, prev.duration}
+	}
+	return nil
+}
+
+// Record a notification.
+func (s *cycleStatus) notified(notifier string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.notifications[notifier] == nil {
+		s.notifications[notifier] = make(map[bool]int)
+	}
+	s.notifications[notifier][ok]++
+	s.events.publish(event{Type: eventNotified, Notifier: notifier, OK: &ok})
+}
+
+func (s *cycleStatus) start(now time.Time, id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = now
+	s.startedID = id
+	s.events.publish(event{Type: eventCycleStarted, Time: now, CycleID: id})
+}
+
+func (s *cycleStatus) finish(now time.Time, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = now
+	s.finishedID = s.startedID
+	s.failed = failed
+	s.succeeded = s.succeeded || !failed
+	s.cycles++
+	s.events.publish(event{Type: eventCycleFinished, Time: now,
+		CycleID: s.finishedID, Failed: &failed})
+}
+
+func (s *cycleStatus) schedule(next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next = next
+}
+
+// Whether every cycle so far has failed.
+func (s *cycleStatus) neverSucceeded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.succeeded
+}
+
+// Record registrations of domains looked up in a cycle.
+func (s *cycleStatus) recordDomains(domains []domainReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.domains = domains
+}
+
+// Whether the first cycle has finished.
+func (s *cycleStatus) ready() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.finished.IsZero()
+}
+
+// Whether the last cycle didn't fail catastrophically and
+// a cycle has finished within twice the interval.
+func (s *cycleStatus) healthy(now time.Time, interval time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed {
+		return false
+	}
+	last := s.finished
+	if last.IsZero() {
+		last = s.booted
+	}
+	return now.Sub(last) <= 2*interval
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// A response of GET /status.
+type statusResponse struct {
+	Version  string        `json:"version"`
+	Config   configSummary `json:"config"`
+	Started  time.Time     `json:"last_cycle_started"`
+	Finished time.Time     `json:"last_cycle_finished"`
+	// Ids of cycles requested by POST /check and the ticker.
+	StartedID  int          `json:"last_cycle_started_id"`
+	FinishedID int          `json:"last_cycle_finished_id"`
+	Next       time.Time    `json:"next_cycle"`
+	Stale      bool         `json:"stale"`
+	Hosts      []hostReport `json:"hosts"`
+	// Registrations of domains if DOMAIN_CHECK.
+	Domains []domainReport `json:"domains,omitempty"`
+}
+
+// A summary of config in GET /status.
+type configSummary struct {
+	Hosts           int    `json:"hosts"`
+	ThresholdDays   int    `json:"threshold_days"`
+	CriticalDays    int    `json:"critical_days"`
+	IntervalSeconds int64  `json:"interval_seconds"`
+	Interval        string `json:"interval"`
+}
+
+// Build a response of GET /status.
+func (s *cycleStatus) statusResponse(config *config, now time.Time) *statusResponse {
+	hosts := s.hostReports(config, now)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &statusResponse{
+		Version: version,
+		Config: configSummary{
+			Hosts:           len(hosts),
+			ThresholdDays:   config.thresholdDays,
+			CriticalDays:    config.criticalDays,
+			IntervalSeconds: int64(config.interval.Seconds()),
+			Interval:        config.interval.String(),
+		},
+		Started:    s.started,
+		Finished:   s.finished,
+		StartedID:  s.startedID,
+		FinishedID: s.finishedID,
+		Next:       s.next,
+		Stale:      now.Sub(s.finished) > config.interval,
+		Hosts:      hosts,
+		Domains:    s.domains,
+	}
+}
+
+// Serve the status in JSON from memory.
+// Returns 503 until the first cycle finishes.
+func statusHandler(config *config, status *cycleStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !status.ready() {
+			http.Error(w, "first check not finished",
+				http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(status.statusResponse(config, time.Now())); err != nil {
+			log.Printf("ERROR writing status: %v", err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A rule of THRESHOLD_RULES mapping hosts matching a pattern to a
+// threshold.
+type thresholdRule struct {
+	pattern *regexp.Regexp
+	days    int
+}
+
+// Ordered rules of thresholds, where the first match wins.
+type thresholdRules struct {
+	rules []thresholdRule
+	// Guards resolved.
+	mu sync.Mutex
+	// Rules resolved by hosts, nil if they match none.
+	resolved map[string]*thresholdRule
+}
+
+// Read rules in a file of lines like
+//
+//	^.*\.prod\.example\.com$ => 45
+//
+// Blank lines and lines starting with # are ignored.
+func loadThresholdRules(path string) (*thresholdRules, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rules := &thresholdRules{resolved: make(map[string]*thresholdRule)}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, "=>")
+		if i < 0 {
+			return nil, fmt.Errorf("Missing => in line %v of %v", n, path)
+		}
+		pattern, err := regexp.Compile(strings.TrimSpace(line[:i]))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern in line %v of %v: %v", n, path, err)
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(line[i+2:]))
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("Invalid days in line %v of %v", n, path)
+		}
+		rules.rules = append(rules.rules, thresholdRule{pattern, days})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// The first rule a host matches, or nil if it matches none.
+func (config *config) ruleOf(host string) *thresholdRule {
+	rules := config.thresholdRules
+	if rules == nil {
+		return nil
+	}
+	rules.mu.Lock()
+	defer rules.mu.Unlock()
+	if rule, ok := rules.resolved[host]; ok {
+		return rule
+	}
+	var matched *thresholdRule
+	for i, rule := range rules.rules {
+		if rule.pattern.MatchString(host) {
+			config.debugf("%v matched threshold rule %v => %v", host, rule.pattern, rule.days)
+			matched = &rules.rules[i]
+			break
+		}
+	}
+	if matched == nil {
+		config.debugf("%v matched no threshold rule", host)
+	}
+	rules.resolved[host] = matched
+	return matched
+}
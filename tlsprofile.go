@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"time"
+)
+
+// A TLS profile of Mozilla's server side TLS guidelines.
+type tlsProfile struct {
+	name       string
+	minVersion uint16
+	// Cipher suites allowed for TLS 1.2 and below. Nil for any.
+	// Cipher suites of TLS 1.3 are always allowed.
+	cipherSuites []uint16
+}
+
+// Profiles selectable by TLS_PROFILE.
+var tlsProfiles = map[string]*tlsProfile{
+	"modern": {name: "modern", minVersion: tls.VersionTLS13},
+	"intermediate": {
+		name:       "intermediate",
+		minVersion: tls.VersionTLS12,
+		cipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	},
+	"old": {name: "old", minVersion: tls.VersionTLS10},
+}
+
+// Read TLS_PROFILE. Nil if it's not set. Exit process if it's unknown.
+func readTLSProfile() *tlsProfile {
+	name := envOptional("TLS_PROFILE", "")
+	if name == "" {
+		return nil
+	}
+	profile, ok := tlsProfiles[name]
+	if !ok {
+		log.Fatalf("Unknown TLS_PROFILE %q, expected modern, intermediate or old", name)
+	}
+	return profile
+}
+
+// The requirement of the profile violated by a version and a cipher
+// suite, or "" if they're within the profile.
+func (p *tlsProfile) violation(version, cipherSuite uint16) string {
+	if version < p.minVersion {
+		return fmt.Sprintf("%v below %v required by %v", tls.VersionName(version),
+			tls.VersionName(p.minVersion), p.name)
+	}
+	if version >= tls.VersionTLS13 || p.cipherSuites == nil {
+		return ""
+	}
+	for _, allowed := range p.cipherSuites {
+		if cipherSuite == allowed {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%v not allowed by %v", tls.CipherSuiteName(cipherSuite), p.name)
+}
+
+// Check whether a target can negotiate within the profile, given the
+// result of the default handshake. Returns the requirement violated,
+// or "" if it can. Another handshake restricted to the profile is made
+// only when the default one is outside the profile.
+func (p *tlsProfile) check(t target, r *result, timeout time.Duration) string {
+	if r.tlsVersion == 0 {
+		return ""
+	}
+	violation := p.violation(r.tlsVersion, r.cipherSuite)
+	if violation == "" {
+		return ""
+	}
+	cfg := tlsConfig(t)
+	cfg.MinVersion = p.minVersion
+	cfg.CipherSuites = p.cipherSuites
+	if cfg.ServerName == "" {
+		cfg.ServerName, _, _ = net.SplitHostPort(hostPort(t.origin()))
+	}
+	raw, err := net.DialTimeout("tcp", t.dialAddr(), timeout)
+	if err != nil {
+		return violation
+	}
+	defer raw.Close()
+	raw.SetDeadline(time.Now().Add(timeout))
+	if err := startTLS(raw, t.protocol()); err != nil {
+		return violation
+	}
+	conn := tls.Client(raw, cfg)
+	if err := conn.Handshake(); err != nil {
+		return violation
+	}
+	state := conn.ConnectionState()
+	return p.violation(state.Version, state.CipherSuite)
+}
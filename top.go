@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// The n hosts closest to expiry regardless of thresholds, soonest first.
+// Hosts failing checks are left out.
+func topExpiring(reports []hostReport, n int) []hostReport {
+	var top []hostReport
+	for _, r := range reports {
+		if r.Error == "" && r.NotAfter != nil {
+			top = append(top, r)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].NotAfter.Before(*top[j].NotAfter)
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// Lines of hosts closest to expiry like "1. www.example.com: 12 days (...)".
+func topExpiringText(top []hostReport) string {
+	var buf bytes.Buffer
+	for i, r := range top {
+		fmt.Fprintf(&buf, "%v. %v: %v days (%v)\n", i+1, r.Host,
+			math.Floor(*r.DaysRemaining), r.NotAfter.Format("2006-01-02"))
+	}
+	return buf.String()
+}
+
+// Log TOP_N hosts closest to expiry of a cycle.
+func (config *config) logTopExpiring(checks []*hostCheck, now time.Time) {
+	if config.topN <= 0 {
+		return
+	}
+	reports := make([]hostReport, 0, len(checks))
+	for _, c := range checks {
+		reports = append(reports, newHostReport(config, c, now))
+	}
+	top := topExpiring(reports, config.topN)
+	for i, r := range top {
+		log.Printf("Next to expire #%v: %v in %v days at %v", i+1, r.Host,
+			math.Floor(*r.DaysRemaining), r.NotAfter)
+	}
+}
+
+// Serve hosts closest to expiry in JSON, as many as ?n= or TOP_N.
+func topHandler(config *config, status *cycleStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !status.ready() {
+			http.Error(w, "first check not finished",
+				http.StatusServiceUnavailable)
+			return
+		}
+		n := config.topN
+		if value := r.URL.Query().Get("n"); value != "" {
+			var err error
+			if n, err = strconv.Atoi(value); err != nil || n <= 0 {
+				http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+		if n <= 0 {
+			http.Error(w, "TOP_N is not set; give n", http.StatusBadRequest)
+			return
+		}
+		top := topExpiring(status.hostReports(config, time.Now()), n)
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(top); err != nil {
+			log.Printf("ERROR writing top: %v", err)
+		}
+	}
+}
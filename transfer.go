@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// The version of the schema of exports by "sslreminder state export".
+const exportSchemaVersion = 1
+
+// State of an instance exported to move or merge it into another.
+// Sections are omitted if their files are not configured.
+type stateExport struct {
+	SchemaVersion int       `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	// STATE_FILE with reminders and acknowledgements.
+	State *state `json:"state,omitempty"`
+	// MUTE_FILE.
+	Mutes map[string]muteWindow `json:"mutes,omitempty"`
+	// HISTORY_FILE, which renewals are learned from.
+	History []historyRecord `json:"history,omitempty"`
+}
+
+// Export or import state like "sslreminder state export [file]" or
+// "sslreminder state import [--merge] <file>".
+// Exit process on errors.
+func stateCommand(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("Usage: sslreminder state export [file] | import [--merge] <file>")
+	}
+	switch args[0] {
+	case "export":
+		exportState(args[1:])
+	case "import":
+		importState(args[1:])
+	default:
+		log.Fatalf("Unknown state command %q", args[0])
+	}
+}
+
+// Write STATE_FILE, MUTE_FILE and HISTORY_FILE as a JSON to a file or
+// stdout.
+func exportState(args []string) {
+	if len(args) > 1 {
+		log.Fatalf("Usage: sslreminder state export [file]")
+	}
+	export := stateExport{SchemaVersion: exportSchemaVersion, ExportedAt: time.Now()}
+	var err error
+	if path := envOptional("STATE_FILE", ""); path != "" {
+		if export.State, err = loadState(path); err != nil {
+			log.Fatalf("Failed to load state: %v", err)
+		}
+	}
+	if path := envOptional("MUTE_FILE", ""); path != "" {
+		if export.Mutes, err = loadMutes(path); err != nil {
+			log.Fatalf("Failed to load mutes: %v", err)
+		}
+	}
+	if path := envOptional("HISTORY_FILE", ""); path != "" {
+		if export.History, err = loadHistoryRecords(path); err != nil {
+			log.Fatalf("Failed to load history: %v", err)
+		}
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode state: %v", err)
+	}
+	if len(args) == 0 {
+		os.Stdout.Write(append(data, '\n'))
+		return
+	}
+	if err := writeFileAtomic(args[0], data); err != nil {
+		log.Fatalf("Failed to write %v: %v", args[0], err)
+	}
+	log.Printf("Exported state to %v", args[0])
+}
+
+// Read an export, validating and migrating its state.
+func loadStateExport(path string) (*stateExport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var export stateExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", path, err)
+	}
+	if export.SchemaVersion > exportSchemaVersion {
+		return nil, fmt.Errorf("%v is of schema version %v, newer than %v supported by this sslreminder; upgrade sslreminder",
+			path, export.SchemaVersion, exportSchemaVersion)
+	}
+	if export.SchemaVersion < 1 {
+		return nil, fmt.Errorf("%v is not an export of sslreminder", path)
+	}
+	if export.State != nil {
+		if err := export.State.migrate(); err != nil {
+			return nil, fmt.Errorf("Failed to load %v: %v", path, err)
+		}
+	}
+	return &export, nil
+}
+
+// Replace or merge STATE_FILE, MUTE_FILE and HISTORY_FILE with an export.
+// Merges keep the most recent record of each host, or each check of
+// history. Instances using the files should be stopped meanwhile.
+func importState(args []string) {
+	flags := flag.NewFlagSet("state import", flag.ExitOnError)
+	merge := flags.Bool("merge", false, "merge into existing state rather than replacing it")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatalf("Usage: sslreminder state import [--merge] <file>")
+	}
+	export, err := loadStateExport(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to import state: %v", err)
+	}
+
+	if export.State != nil {
+		path := envMandatory("STATE_FILE")
+		s := export.State
+		if *merge {
+			current, err := loadState(path)
+			if err != nil {
+				log.Fatalf("Failed to load state: %v", err)
+			}
+			if current != nil {
+				current.merge(s)
+				s = current
+			}
+		}
+		if err := saveState(path, s); err != nil {
+			log.Fatalf("Failed to save state: %v", err)
+		}
+		log.Printf("Imported state of %v hosts", len(export.State.Hosts))
+	}
+
+	if export.Mutes != nil {
+		path := envMandatory("MUTE_FILE")
+		mutes := export.Mutes
+		if *merge {
+			current, err := loadMutes(path)
+			if err != nil {
+				log.Fatalf("Failed to load mutes: %v", err)
+			}
+			for host, w := range mutes {
+				if mine, ok := current[host]; !ok || w.From.After(mine.From) {
+					current[host] = w
+				}
+			}
+			mutes = current
+		}
+		if err := saveMutes(path, mutes); err != nil {
+			log.Fatalf("Failed to save mutes: %v", err)
+		}
+		log.Printf("Imported mutes of %v hosts", len(export.Mutes))
+	}
+
+	if export.History != nil {
+		path := envMandatory("HISTORY_FILE")
+		records := export.History
+		if *merge {
+			current, err := loadHistoryRecords(path)
+			if err != nil {
+				log.Fatalf("Failed to load history: %v", err)
+			}
+			records = mergeHistoryRecords(current, records)
+		}
+		if err := saveHistoryRecords(path, records); err != nil {
+			log.Fatalf("Failed to save history: %v", err)
+		}
+		log.Printf("Imported %v checks of history", len(export.History))
+	}
+}
+
+// Union of records without duplicate checks, oldest first.
+func mergeHistoryRecords(a, b []historyRecord) []historyRecord {
+	type key struct {
+		host      string
+		checkedAt int64
+	}
+	seen := make(map[key]bool, len(a)+len(b))
+	var merged []historyRecord
+	for _, rec := range append(append([]historyRecord(nil), a...), b...) {
+		k := key{rec.Host, rec.CheckedAt.UnixNano()}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		merged = append(merged, rec)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CheckedAt.Before(merged[j].CheckedAt)
+	})
+	return merged
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// Modes of verification of certificates served by hosts.
+const (
+	// Verify chains and host names.
+	verifyFull = "full"
+	// Verify chains without host names.
+	verifyChain = "chain"
+	// Read certificates without verifying them, reporting why they would
+	// fail.
+	verifyNone = "none"
+)
+
+// The mode of verification of targets without verify tags, by VERIFY,
+// set by readConfig.
+var defaultVerification = verifyFull
+
+// Validate a mode of verification.
+func validateVerification(mode string) error {
+	switch mode {
+	case verifyFull, verifyChain, verifyNone:
+		return nil
+	default:
+		return fmt.Errorf("Unknown verification %q, which must be full, chain or none", mode)
+	}
+}
+
+// The mode of verification of a target, given by a verify tag like
+// internal.example.com;verify=none.
+// Targets discovered by their own DNS names only verify chains.
+func (t target) verification() string {
+	if mode, ok := t.tags["verify"]; ok {
+		return mode
+	}
+	if t.ignoreName {
+		return verifyChain
+	}
+	return defaultVerification
+}
+
+// The chain of certificates served to a target by its verification and
+// a description of the verification. Certificates failing verification
+// are only returned in verifyNone, with the reason in the description.
+// verified are chains verified in the handshake if any.
+func verifyPeer(t target, certs []*x509.Certificate, serverName string,
+	verified [][]*x509.Certificate) ([]*x509.Certificate, string, error) {
+	switch t.verification() {
+	case verifyNone:
+		opts := x509.VerifyOptions{DNSName: serverName, Intermediates: x509.NewCertPool()}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		if _, err := certs[0].Verify(opts); err != nil {
+			return certs, fmt.Sprintf("skipped, would fail: %v", err), nil
+		}
+		return certs, "skipped", nil
+	case verifyChain:
+		// Verified in VerifyConnection without the hostname.
+		chains, err := verifyChains(certs)
+		if err != nil {
+			return nil, "", err
+		}
+		return chains[0], "verified without name", nil
+	default:
+		return verified[0], "verified", nil
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"time"
+)
+
+// The kind of changes of certificates found by watching a host.
+const changeCertificate = "certificate changed"
+
+// The SHA-256 fingerprint of the leaf of a result.
+func leafFingerprint(r *result) string {
+	if len(r.chain) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(r.chain[0].Raw))
+}
+
+// A description of how a certificate changed, or "" if it didn't.
+func certificateChange(before, after *result) string {
+	if leafFingerprint(before) == leafFingerprint(after) &&
+		before.notAfter.Equal(after.notAfter) {
+		return ""
+	}
+	return fmt.Sprintf("fingerprint %v -> %v, expiration %v -> %v",
+		leafFingerprint(before), leafFingerprint(after), before.notAfter, after.notAfter)
+}
+
+// Poll WATCH_HOST every WATCH_INTERVAL during maintenance, alerting via
+// notifiers as soon as its certificate changes.
+// It stops after WATCH_DURATION, or on the first change if
+// WATCH_EXIT_ON_CHANGE.
+func watch(host string) {
+	config := readConfig()
+	notifiers := readNotifiers(config, readSendgridConfig())
+	t, err := parseTarget(host)
+	if err != nil {
+		log.Fatalf("Failed to parse WATCH_HOST: %v", err)
+	}
+	interval := envOptionalDuration("WATCH_INTERVAL", 5*time.Second)
+	duration := envOptionalDuration("WATCH_DURATION", time.Hour)
+	exitOnChange := envOptionalBool("WATCH_EXIT_ON_CHANGE", true)
+
+	log.Printf("Watching %v every %v for %v", t.host, interval, duration)
+	deadline := time.Now().Add(duration)
+	var last *result
+	var lastAt time.Time
+	for ; time.Now().Before(deadline); time.Sleep(interval) {
+		r, err := GetResult(t, config.timeout)
+		now := time.Now()
+		if err != nil {
+			log.Printf("ERROR getting expiration time of %v: %v", t.host, err)
+			continue
+		}
+		if last == nil {
+			log.Printf("%v serves %v expiring at %v", t.host, leafFingerprint(r), r.notAfter)
+			last, lastAt = r, now
+			continue
+		}
+		detail := certificateChange(last, r)
+		if detail == "" {
+			last, lastAt = r, now
+			continue
+		}
+		log.Printf("Certificate of %v changed: %v", t.host, detail)
+		c := &hostCheck{t, r, nil, now, 0}
+		rem := newReminder(config, now, []target{t}, []*hostCheck{c},
+			map[string]*result{t.host: r}, nil)
+		rem.Subject = fmt.Sprintf("CHANGED SSL certificate of %v", t.host)
+		rem.prevCycle = &cycleSnapshot{FinishedAt: lastAt}
+		rem.CycleChanges = []changeReport{{t.host, changeCertificate, detail}}
+		for _, n := range notifiers {
+			if err := n.notify(rem); err != nil && err != errNotifySkipped {
+				log.Printf("ERROR alerting via %v: %v", n.name(), err)
+			}
+		}
+		if exitOnChange {
+			return
+		}
+		last, lastAt = r, now
+	}
+	log.Printf("Stopped watching %v after %v", t.host, duration)
+}
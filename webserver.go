@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A server block of a web server config.
+type serverBlock struct {
+	names    []string
+	certFile string
+	// The config file the block was found in.
+	file string
+}
+
+// Discovers certificate files and server names from web server configs.
+type webServerSource struct {
+	kind  string
+	glob  string
+	root  string
+	probe bool
+	parse func(path, root string) ([]serverBlock, error)
+}
+
+// Read nginx and Apache config discovery configs.
+func readWebServerSources() []source {
+	probe := envOptionalBool("CONF_PROBE", false)
+	var sources []source
+	if glob := envOptional("NGINX_CONF_GLOB", ""); len(glob) > 0 {
+		sources = append(sources, &webServerSource{
+			kind:  "nginx",
+			glob:  glob,
+			root:  envOptional("NGINX_PREFIX", "/etc/nginx"),
+			probe: probe,
+			parse: parseNginxConf,
+		})
+	}
+	if glob := envOptional("APACHE_CONF_GLOB", ""); len(glob) > 0 {
+		sources = append(sources, &webServerSource{
+			kind:  "Apache",
+			glob:  glob,
+			root:  envOptional("APACHE_SERVER_ROOT", "/etc/apache2"),
+			probe: probe,
+			parse: parseApacheConf,
+		})
+	}
+	return sources
+}
+
+func (w *webServerSource) name() string {
+	return w.kind
+}
+
+// Targets of server blocks having certificates, one per server name.
+// Blocks without names are named after their certificate files.
+func (w *webServerSource) discover() ([]target, error) {
+	paths, err := filepath.Glob(w.glob)
+	if err != nil {
+		return nil, err
+	}
+	var targets []target
+	for _, path := range paths {
+		blocks, err := w.parse(path, w.root)
+		if err != nil {
+			log.Printf("ERROR parsing %v: %v", path, err)
+			continue
+		}
+		for _, b := range blocks {
+			if b.certFile == "" {
+				continue
+			}
+			certFile := resolvePath(w.root, b.certFile)
+			src := fmt.Sprintf("%v %v", w.kind, b.file)
+			if len(b.names) == 0 {
+				targets = append(targets, target{
+					host: certFile, source: src, certFile: certFile})
+				continue
+			}
+			for _, name := range b.names {
+				targets = append(targets, target{
+					host:     name,
+					source:   src,
+					certFile: certFile,
+					probe:    w.probe,
+				})
+			}
+		}
+	}
+	return targets, nil
+}
+
+// Resolve a path in a config relative to the server root.
+func resolvePath(root, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(root, path)
+}
+
+// Read certificates in a PEM file, leaf first.
+func readCertFile(path string) ([]*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := parsePEMCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("No certificates found in %v", path)
+	}
+	return certs, nil
+}
+
+// Split an nginx config into tokens.
+// ";", "{" and "}" are separate tokens, and comments and quotes are removed.
+func nginxTokens(data string) []string {
+	var tokens []string
+	for _, line := range strings.Split(data, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.NewReplacer(";", " ; ", "{", " { ", "}", " } ").
+			Replace(line)
+		for _, token := range strings.Fields(line) {
+			tokens = append(tokens, strings.Trim(token, `"'`))
+		}
+	}
+	return tokens
+}
+
+// Tokens of an nginx config, expanding include directives one level.
+func nginxExpandedTokens(path, prefix string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tokens := nginxTokens(string(data))
+	var expanded []string
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] != "include" || i+2 >= len(tokens) || tokens[i+2] != ";" {
+			expanded = append(expanded, tokens[i])
+			continue
+		}
+		includes, err := filepath.Glob(resolvePath(prefix, tokens[i+1]))
+		if err != nil {
+			return nil, err
+		}
+		for _, include := range includes {
+			data, err := ioutil.ReadFile(include)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, nginxTokens(string(data))...)
+		}
+		i += 2
+	}
+	return expanded, nil
+}
+
+// Parse server blocks of an nginx config.
+func parseNginxConf(path, prefix string) ([]serverBlock, error) {
+	tokens, err := nginxExpandedTokens(path, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []serverBlock
+	var current *serverBlock
+	depth, serverDepth := 0, -1
+	var directive []string
+	for _, token := range tokens {
+		switch token {
+		case "{":
+			if len(directive) == 1 && directive[0] == "server" {
+				current = &serverBlock{file: path}
+				serverDepth = depth
+			}
+			directive = nil
+			depth++
+		case "}":
+			depth--
+			if current != nil && depth == serverDepth {
+				blocks = append(blocks, *current)
+				current = nil
+			}
+			directive = nil
+		case ";":
+			if current != nil && len(directive) > 1 {
+				switch directive[0] {
+				case "server_name":
+					for _, name := range directive[1:] {
+						if name != "_" && !strings.Contains(name, "*") &&
+							!strings.HasPrefix(name, "~") {
+							current.names = append(current.names, name)
+						}
+					}
+				case "ssl_certificate":
+					current.certFile = directive[1]
+				}
+			}
+			directive = nil
+		default:
+			directive = append(directive, token)
+		}
+	}
+	return blocks, nil
+}
+
+// Lines of an Apache config, expanding Include directives one level.
+func apacheLines(path, root string, expand bool) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		directive := strings.ToLower(fields[0])
+		if expand && len(fields) > 1 &&
+			(directive == "include" || directive == "includeoptional") {
+			includes, err := filepath.Glob(
+				resolvePath(root, strings.Trim(fields[1], `"`)))
+			if err != nil {
+				return nil, err
+			}
+			for _, include := range includes {
+				included, err := apacheLines(include, root, false)
+				if err != nil {
+					return nil, err
+				}
+				lines = append(lines, included...)
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// Parse VirtualHost blocks of an Apache config.
+func parseApacheConf(path, root string) ([]serverBlock, error) {
+	lines, err := apacheLines(path, root, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []serverBlock
+	var current *serverBlock
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		for i := range fields {
+			fields[i] = strings.Trim(fields[i], `"`)
+		}
+		switch directive := strings.ToLower(fields[0]); {
+		case strings.HasPrefix(directive, "<virtualhost"):
+			current = &serverBlock{file: path}
+		case directive == "</virtualhost>":
+			if current != nil {
+				blocks = append(blocks, *current)
+				current = nil
+			}
+		case current == nil || len(fields) < 2:
+		case directive == "servername" || directive == "serveralias":
+			for _, name := range fields[1:] {
+				if !strings.Contains(name, "*") {
+					current.names = append(current.names, name)
+				}
+			}
+		case directive == "sslcertificatefile":
+			current.certFile = fields[1]
+		}
+	}
+	return blocks, nil
+}